@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/did"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+var (
+	// Assemble command flags
+	assembleIssuerDidKey string
+	assembleRecord       bool
+)
+
+// assembleCmd represents the assemble command
+var assembleCmd = &cobra.Command{
+	Use:   "assemble PAYLOAD SIGNATURE",
+	Short: "Assemble a delegation from an unsigned payload and its signature",
+	Long: `Combines an unsigned UCAN payload written by gen --unsigned-out with the signature produced by sign, verifies
+   the signature and outputs the delegation. The signature of a did:web issuer is verified with the key passed
+   with --issuer-did-key.
+   Examples:
+     - Assemble: mkdelegation assemble payload.cbor sig.bin > delegation.b64
+     - Assemble a did:web delegation: mkdelegation assemble payload.cbor sig.bin --issuer-did-key did:key:z6Mk...`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         assembleDelegation,
+}
+
+func init() {
+	rootCmd.AddCommand(assembleCmd)
+
+	assembleCmd.Flags().StringVar(&assembleIssuerDidKey, "issuer-did-key", "", "did:key of the issuer key, required to verify the signature of a did:web issuer")
+	assembleCmd.Flags().BoolVar(&assembleRecord, "record", false, "record the delegation in the local issuance ledger")
+}
+
+func assembleDelegation(cmd *cobra.Command, args []string) error {
+	payload, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading payload: %w", err)
+	}
+	sig, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	u, err := mkd.DecodePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	keyDID := u.Issuer().DID()
+	if assembleIssuerDidKey != "" {
+		keyDID, err = did.Parse(assembleIssuerDidKey)
+		if err != nil {
+			return fmt.Errorf("parsing issuer did key: %w", err)
+		}
+	} else if !strings.HasPrefix(keyDID.String(), "did:key:") {
+		return fmt.Errorf("issuer %s is not a did:key, pass the did:key of its key with --issuer-did-key", keyDID)
+	}
+	verifier, err := mkd.ResolveDIDKey(keyDID)
+	if err != nil {
+		return err
+	}
+
+	d, err := mkd.Assemble(payload, sig, verifier)
+	if err != nil {
+		return fmt.Errorf("assembling delegation: %w", err)
+	}
+	if err := enforcePolicy(d); err != nil {
+		return err
+	}
+
+	out, err := mkd.FormatDelegation(d.Archive())
+	if err != nil {
+		return fmt.Errorf("formatting delegation: %w", err)
+	}
+
+	if assembleRecord {
+		if err := recordDelegation(d); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(out)
+	return nil
+}
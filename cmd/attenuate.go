@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/did"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+var (
+	// Attenuate command flags
+	attenuateFrom             string
+	attenuateIssuerPrivateKey string
+	attenuateIssuerKeyURI     string
+	attenuateIssuerDidWebKey  string
+	attenuateAudienceDidKey   string
+	attenuateCapabilities     []string
+	attenuateExpiresIn        string
+)
+
+// attenuateCmd represents the attenuate command
+var attenuateCmd = &cobra.Command{
+	Use:     "attenuate",
+	Aliases: []string{"a"},
+	Short:   "Re-delegate a subset of the capabilities of an existing delegation",
+	Long: `Re-delegates a subset of the capabilities of a parent delegation to a new audience. The issuer must be
+   the audience of the parent delegation, which is included as proof. Abilities not granted by the parent, and
+   expirations beyond that of the parent, are refused. The parent expiration is inherited when --expires-in
+   is not provided.
+   Examples:
+     - Attenuate: mkdelegation attenuate --from parent.b64 -i my-key.pem -a did:key:z6Mkh... -c blob/allocate --expires-in 24h`,
+	SilenceUsage: true,
+	RunE:         attenuateDelegation,
+}
+
+func init() {
+	rootCmd.AddCommand(attenuateCmd)
+
+	attenuateCmd.Flags().StringVarP(&attenuateFrom, "from", "f", "", "Path to the parent delegation")
+	Must(attenuateCmd.MarkFlagRequired("from"))
+
+	attenuateCmd.Flags().StringVarP(&attenuateIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of delegation issuer")
+	attenuateCmd.Flags().StringVar(&attenuateIssuerKeyURI, "issuer", "", "URI of the delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	attenuateCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	attenuateCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	attenuateCmd.Flags().StringVarP(&attenuateIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, when provided warps did:key: of delegation issuer")
+
+	attenuateCmd.Flags().StringVarP(&attenuateAudienceDidKey, "audience-did-key", "a", "", "did:key of delegation audience")
+	Must(attenuateCmd.MarkFlagRequired("audience-did-key"))
+
+	attenuateCmd.Flags().StringArrayVarP(&attenuateCapabilities, "capabilities", "c", []string{}, "list of capabilities of the parent delegation issuer will authorize to audience")
+	Must(attenuateCmd.MarkFlagRequired("capabilities"))
+
+	attenuateCmd.Flags().StringVar(&attenuateExpiresIn, "expires-in", "", "duration after which the delegation expires (e.g. 24h, 90d)")
+}
+
+func attenuateDelegation(cmd *cobra.Command, args []string) error {
+	parent, err := loadDelegationArg(attenuateFrom)
+	if err != nil {
+		return err
+	}
+
+	issuer, err := loadSigner(attenuateIssuerPrivateKey, attenuateIssuerKeyURI, attenuateIssuerDidWebKey)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(issuer)
+
+	audience, err := did.Parse(attenuateAudienceDidKey)
+	if err != nil {
+		return fmt.Errorf("parsing audience did key: %w", err)
+	}
+
+	var expiration *int
+	if attenuateExpiresIn != "" {
+		d, err := policy.ParseDuration(attenuateExpiresIn)
+		if err != nil {
+			return fmt.Errorf("parsing expires in: %w", err)
+		}
+		exp := int(time.Now().Add(d).Unix())
+		expiration = &exp
+	}
+
+	checked, err := policyIssuer(issuer)
+	if err != nil {
+		return err
+	}
+	d, err := mkd.Attenuate(checked, audience, parent, attenuateCapabilities, expiration)
+	if err != nil {
+		return fmt.Errorf("attenuating delegation: %w", err)
+	}
+
+	out, err := mkd.FormatDelegation(d.Archive())
+	if err != nil {
+		return fmt.Errorf("formatting delegation: %w", err)
+	}
+	fmt.Println(out)
+	return nil
+}
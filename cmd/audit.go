@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+var (
+	// Audit command flags
+	auditJsonOutput  bool
+	auditExtensions  []string
+	auditConcurrency int
+	auditWarnWithin  string
+)
+
+// Audit finding kinds
+const (
+	findingUnparseable = "unparseable"
+	findingExpired     = "expired"
+	findingExpiring    = "expiring"
+	findingNotYetValid = "not-yet-valid"
+	findingNoExpiry    = "no-expiry"
+	findingUnknown     = "unknown-ability"
+	findingWildcard    = "wildcard"
+	findingDuplicate   = "duplicate"
+	findingBrokenChain = "broken-chain"
+)
+
+// auditFinding is a problem found in an audited delegation file
+type auditFinding struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// auditResult holds the findings of a single delegation file
+type auditResult struct {
+	File     string         `json:"file"`
+	CID      string         `json:"cid,omitempty"`
+	Findings []auditFinding `json:"findings"`
+}
+
+// auditCmd represents the audit command
+var auditCmd = &cobra.Command{
+	Use:   "audit DIRECTORY",
+	Short: "Audit a directory of UCAN delegations",
+	Long: `Parses every delegation file in a directory (recursively) and reports expired, expiring and not yet valid
+   delegations, delegations without expiry, abilities that are not known capabilities, wildcard grants, duplicate
+   delegations and broken proof chains.
+   Examples:
+     - Audit a directory: mkdelegation audit ./delegations
+     - Audit as JSON: mkdelegation audit --json ./delegations`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         auditDelegations,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+
+	auditCmd.Flags().BoolVarP(&auditJsonOutput, "json", "j", false, "Output in JSON format")
+	auditCmd.Flags().StringSliceVar(&auditExtensions, "ext", []string{".b64"}, "File extensions of delegation files, empty to audit every file")
+	auditCmd.Flags().IntVar(&auditConcurrency, "concurrency", runtime.NumCPU(), "Number of files parsed concurrently")
+	auditCmd.Flags().StringVar(&auditWarnWithin, "warn-within", "7d", "Report delegations expiring within this duration as expiring (e.g. 72h, 7d)")
+}
+
+func auditDelegations(cmd *cobra.Command, args []string) error {
+	warnWithin, err := policy.ParseDuration(auditWarnWithin)
+	if err != nil {
+		return fmt.Errorf("parsing warn within: %w", err)
+	}
+
+	files, err := findDelegationFiles(args[0], auditExtensions)
+	if err != nil {
+		return fmt.Errorf("scanning %s: %w", args[0], err)
+	}
+
+	results := make([]auditResult, len(files))
+	paths := make(chan int)
+	var wg sync.WaitGroup
+	now := time.Now()
+	for range max(auditConcurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range paths {
+				results[i] = auditFile(files[i], now, warnWithin)
+			}
+		}()
+	}
+	for i := range files {
+		paths <- i
+	}
+	close(paths)
+	wg.Wait()
+
+	flagDuplicates(results)
+
+	if auditJsonOutput {
+		jsonOutput, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit results to JSON: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(jsonOutput))
+		return nil
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), formatAuditAsTable(results))
+	return nil
+}
+
+// findDelegationFiles returns the files under the directory with one of the
+// provided extensions, in lexical order
+func findDelegationFiles(dir string, extensions []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(extensions) == 0 || slices.Contains(extensions, filepath.Ext(path)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// auditFile parses a delegation file and checks it and its proof chain
+func auditFile(path string, now time.Time, warnWithin time.Duration) auditResult {
+	result := auditResult{File: path, Findings: []auditFinding{}}
+	add := func(kind, format string, a ...any) {
+		result.Findings = append(result.Findings, auditFinding{Kind: kind, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	info, err := delegation.ParseDelegationFromFile(path)
+	if err != nil {
+		add(findingUnparseable, "%s", err)
+		return result
+	}
+	result.CID = info.CID
+
+	delegation.AnnotateExpiry(info, now, warnWithin)
+	delegation.Walk(info, func(d *delegation.DelegationInfo) {
+		switch d.Status {
+		case delegation.StatusExpired:
+			add(findingExpired, "%s expired at %s", d.CID, formatExpiry(d.Expiration))
+		case delegation.StatusExpiring:
+			add(findingExpiring, "%s expires at %s", d.CID, formatExpiry(d.Expiration))
+		case delegation.StatusNotYetValid:
+			add(findingNotYetValid, "%s is not valid before %s", d.CID, formatExpiry(&d.NotBefore))
+		}
+		if d.Expiration == nil {
+			add(findingNoExpiry, "%s never expires", d.CID)
+		}
+		for _, c := range d.Capabilities {
+			if strings.Contains(c.Can, "*") {
+				add(findingWildcard, "%s grants %s on %s", d.CID, c.Can, c.With)
+			} else if !delegation.KnownCapabilities[c.Can] {
+				add(findingUnknown, "%s grants unknown ability %s", d.CID, c.Can)
+			}
+		}
+	})
+
+	for _, issue := range delegation.ProofChainIssues(info) {
+		add(findingBrokenChain, "%s", issue)
+	}
+	return result
+}
+
+// flagDuplicates adds a finding to every result whose delegation CID is also
+// found in another file
+func flagDuplicates(results []auditResult) {
+	byCID := map[string][]string{}
+	for _, r := range results {
+		if r.CID != "" {
+			byCID[r.CID] = append(byCID[r.CID], r.File)
+		}
+	}
+	for i, r := range results {
+		files := byCID[r.CID]
+		if len(files) < 2 {
+			continue
+		}
+		var others []string
+		for _, f := range files {
+			if f != r.File {
+				others = append(others, f)
+			}
+		}
+		sort.Strings(others)
+		results[i].Findings = append(results[i].Findings, auditFinding{
+			Kind:   findingDuplicate,
+			Detail: fmt.Sprintf("same delegation as %s", strings.Join(others, ", ")),
+		})
+	}
+}
+
+// formatAuditAsTable formats the audit results as a table with one row per finding
+func formatAuditAsTable(results []auditResult) string {
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"File", "Finding", "Detail"})
+	table.SetAutoWrapText(true)
+	table.SetAutoMergeCellsByColumnIndex([]int{0})
+	table.SetRowLine(true)
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT})
+	table.SetColWidth(60)
+
+	var total int
+	for _, r := range results {
+		if len(r.Findings) == 0 {
+			table.Append([]string{r.File, "ok", ""})
+			continue
+		}
+		for _, f := range r.Findings {
+			table.Append([]string{r.File, f.Kind, f.Detail})
+			total++
+		}
+	}
+	table.Render()
+
+	return fmt.Sprintf("%s%d file(s) audited, %d finding(s)", tableString.String(), len(results), total)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/core/delegation"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// maxInlineBundleSize is the size up to which bundles are written in the
+// multibase-base64-encoded CID form by default, rather than as a CAR file
+const maxInlineBundleSize = 1 << 20
+
+var (
+	// Bundle command flags
+	bundleOut    string
+	bundleFormat string
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle DELEGATION_FILE...",
+	Short: "Bundle several delegations into a single archive",
+	Long: `Bundles delegations read from files into a single CAR archive with one root per delegation, for services
+   that need several delegations delivered together. Files that are themselves bundles contribute all their
+   delegations, and blocks shared by the delegations are included once. The bundle is written in the same
+   multibase-base64-encoded CID form as a single delegation when it is no larger than 1 MiB, and as a CAR file
+   otherwise, unless --format is provided. parse reports each delegation of a bundle.
+   Examples:
+     - Bundle two delegations: mkdelegation bundle node-a.b64 node-b.b64 -o bundle.b64
+     - Always write a CAR file: mkdelegation bundle --format car *.b64 -o bundle.car`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE:         bundleDelegations,
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+
+	bundleCmd.Flags().StringVarP(&bundleOut, "out", "o", "", "File to write the bundle to, stdout when not provided")
+	bundleCmd.Flags().StringVar(&bundleFormat, "format", "auto", "Format of the bundle: auto, cid (multibase-base64-encoded CID), base64 (base64 encoded CID) or car")
+}
+
+func bundleDelegations(cmd *cobra.Command, args []string) error {
+	var ds []delegation.Delegation
+	for _, path := range args {
+		loaded, err := mkd.LoadBundle(path)
+		if err != nil {
+			return fmt.Errorf("loading delegation %s: %w", path, err)
+		}
+		ds = append(ds, loaded...)
+	}
+
+	archive, err := mkd.Bundle(ds...)
+	if err != nil {
+		return fmt.Errorf("bundling delegations: %w", err)
+	}
+
+	format := bundleFormat
+	if format == "auto" {
+		format = "cid"
+		if len(archive) > maxInlineBundleSize {
+			format = "car"
+		}
+	}
+	out, err := encodeArchive(archive, format)
+	if err != nil {
+		return err
+	}
+
+	cmd.PrintErrf("Bundled %d delegations\n", len(ds))
+	return writeOutput(cmd, bundleOut, out)
+}
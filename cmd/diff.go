@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+var (
+	// Diff command flags
+	diffJsonOutput bool
+	diffNoColor    bool
+
+	// diffColor is set when changes are colored
+	diffColor bool
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:     "diff OLD_DELEGATION_FILE NEW_DELEGATION_FILE",
+	Aliases: []string{"d"},
+	Short:   "Compare two UCAN delegations field by field",
+	Long: `Compares two UCAN delegations field by field: issuer, audience, capabilities (added, removed or with
+   changed caveats), time bounds, facts and proofs. Exits with a non-zero status when the delegations differ.
+   Examples:
+     - Compare: mkdelegation diff old.b64 new.b64
+     - Compare as JSON: mkdelegation diff --json old.b64 new.b64`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         diffDelegations,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVarP(&diffJsonOutput, "json", "j", false, "Output in JSON format")
+	diffCmd.Flags().BoolVar(&diffNoColor, "no-color", false, "Disable colored output, which is only used on terminals and when NO_COLOR is not set")
+}
+
+func diffDelegations(cmd *cobra.Command, args []string) error {
+	oldInfo, err := delegation.ParseDelegationFromFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse delegation %s: %w", args[0], err)
+	}
+	newInfo, err := delegation.ParseDelegationFromFile(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to parse delegation %s: %w", args[1], err)
+	}
+
+	changes := delegation.Diff(oldInfo, newInfo)
+
+	out := cmd.OutOrStdout()
+	diffColor = useColor(out)
+	if diffJsonOutput {
+		if changes == nil {
+			changes = []delegation.Change{}
+		}
+		jsonOutput, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal changes to JSON: %w", err)
+		}
+		fmt.Fprintln(out, string(jsonOutput))
+	} else {
+		for _, c := range changes {
+			fmt.Fprintln(out, formatChange(c))
+		}
+	}
+
+	if len(changes) > 0 {
+		return fmt.Errorf("delegations differ: %d change(s)", len(changes))
+	}
+	return nil
+}
+
+// formatChange renders a change as one or two diff-style lines
+func formatChange(c delegation.Change) string {
+	switch c.Kind {
+	case delegation.ChangeAdded:
+		return colorize(colorGreen, fmt.Sprintf("+ %s: %s", c.Field, c.New))
+	case delegation.ChangeRemoved:
+		return colorize(colorRed, fmt.Sprintf("- %s: %s", c.Field, c.Old))
+	default:
+		return colorize(colorYellow, fmt.Sprintf("~ %s:", c.Field)) + "\n" +
+			colorize(colorRed, fmt.Sprintf("  - %s", c.Old)) + "\n" +
+			colorize(colorGreen, fmt.Sprintf("  + %s", c.New))
+	}
+}
+
+// useColor reports whether output to out is colored: unless --no-color or
+// NO_COLOR (https://no-color.org) is set, when out is a terminal
+func useColor(out io.Writer) bool {
+	if diffNoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := out.(*os.File)
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+func colorize(color, s string) string {
+	if !diffColor {
+		return s
+	}
+	return color + s + colorReset
+}
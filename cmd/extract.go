@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+var (
+	// Extract command flags
+	extractProof  string
+	extractFormat string
+	extractOut    string
+)
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract [DELEGATION_FILE]",
+	Short: "Extract a proof delegation from the chain of a delegation",
+	Long: `Reads a delegation from a file or stdin if no file is provided, and exports one of the proofs in its chain,
+   along with the blocks of its own proofs, so it can be re-verified or re-used on its own. The proof is selected
+   with --proof, either by CID or by an index path such as 1.2 for the second proof of the first proof, numbered
+   as in the output of parse, which skips proofs not included in the archive. The CID of the extracted proof is printed on stderr.
+   Examples:
+     - Extract the first proof: mkdelegation extract delegation.b64 --proof 1
+     - Extract a nested proof as a CAR file: mkdelegation extract delegation.b64 --proof 1.2 --format car -o proof.car
+     - Extract a proof by CID and inspect it: mkdelegation extract delegation.b64 --proof bafyrei... | mkdelegation parse`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         extractDelegationProof,
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+
+	extractCmd.Flags().StringVar(&extractProof, "proof", "", "CID or index path (e.g. 1.2) of the proof to extract")
+	Must(extractCmd.MarkFlagRequired("proof"))
+	extractCmd.Flags().StringVar(&extractFormat, "format", "cid", "Format of the extracted proof: cid (multibase-base64-encoded CID), base64 (base64 encoded CID) or car")
+	extractCmd.Flags().StringVarP(&extractOut, "out", "o", "", "File to write the extracted proof to, stdout when not provided")
+}
+
+func extractDelegationProof(cmd *cobra.Command, args []string) error {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	d, err := loadDelegationArg(path)
+	if err != nil {
+		return err
+	}
+
+	proof, err := mkd.ExtractProof(d, extractProof)
+	if err != nil {
+		return fmt.Errorf("extracting proof: %w", err)
+	}
+	archive, err := io.ReadAll(proof.Archive())
+	if err != nil {
+		return fmt.Errorf("archiving proof: %w", err)
+	}
+	out, err := encodeArchive(archive, extractFormat)
+	if err != nil {
+		return err
+	}
+
+	cmd.PrintErrf("Proof %s\n", proof.Link())
+	return writeOutput(cmd, extractOut, out)
+}
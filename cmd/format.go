@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// formatDelegationAsYAML formats a delegation as YAML, using the same field
+// names as the JSON output
+func formatDelegationAsYAML(info *delegation.DelegationInfo) (string, error) {
+	jsonOutput, err := json.Marshal(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal delegation info to JSON: %w", err)
+	}
+
+	// JSON is valid YAML, decoding it into a node preserves the field order
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonOutput, &node); err != nil {
+		return "", fmt.Errorf("failed to convert delegation info to YAML: %w", err)
+	}
+	resetYAMLStyle(&node)
+
+	var yamlOutput strings.Builder
+	enc := yaml.NewEncoder(&yamlOutput)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return "", fmt.Errorf("failed to marshal delegation info to YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal delegation info to YAML: %w", err)
+	}
+	return strings.TrimSuffix(yamlOutput.String(), "\n"), nil
+}
+
+// resetYAMLStyle clears the flow and quoting styles inherited from JSON so the
+// node is rendered in block style
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
+
+// formatDelegationAsMarkdown formats a delegation and its proofs as Markdown
+// tables suitable for runbooks and PR descriptions
+func formatDelegationAsMarkdown(info *delegation.DelegationInfo) string {
+	var b strings.Builder
+	writeDelegationMarkdown(&b, info, "Delegation", 2)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeDelegationMarkdown(b *strings.Builder, info *delegation.DelegationInfo, title string, level int) {
+	heading := strings.Repeat("#", min(level, 6))
+	cell := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "`" + strings.ReplaceAll(s, "|", `\|`) + "`"
+	}
+
+	fmt.Fprintf(b, "%s %s\n\n", heading, title)
+	b.WriteString("| Property | Value |\n")
+	b.WriteString("| --- | --- |\n")
+	fmt.Fprintf(b, "| CID | %s |\n", cell(info.CID))
+	fmt.Fprintf(b, "| Issuer | %s |\n", cell(info.Issuer))
+	fmt.Fprintf(b, "| Audience | %s |\n", cell(info.Audience))
+	fmt.Fprintf(b, "| Version | %s |\n", info.Version)
+	fmt.Fprintf(b, "| Nonce | %s |\n", cell(info.Nonce))
+	fmt.Fprintf(b, "| Signature (b64) | %s |\n", cell(formatSignature(info.Signature)))
+	if info.Expiration != nil {
+		fmt.Fprintf(b, "| Expiration | %d (%s) |\n", *info.Expiration, formatExpiry(info.Expiration))
+	} else {
+		fmt.Fprintf(b, "| Expiration | %s |\n", formatExpiry(info.Expiration))
+	}
+	fmt.Fprintf(b, "| Not Before | %d |\n", info.NotBefore)
+	if info.Status != "" {
+		fmt.Fprintf(b, "| Status | %s |\n", info.Status)
+	}
+	b.WriteString("\n")
+
+	if len(info.Capabilities) > 0 {
+		b.WriteString("| # | Can | With | Caveats |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for i, c := range info.Capabilities {
+			fmt.Fprintf(b, "| %d | %s | %s | %s |\n", i+1, cell(c.Can), cell(c.With), cell(string(c.Nb)))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(info.Facts) > 0 {
+		b.WriteString("| # | Fact |\n")
+		b.WriteString("| --- | --- |\n")
+		for i, f := range info.Facts {
+			fmt.Fprintf(b, "| %d | %s |\n", i+1, cell(string(f)))
+		}
+		b.WriteString("\n")
+	}
+
+	if notes := proofNotes(info); len(notes) > 0 {
+		for _, n := range notes {
+			fmt.Fprintf(b, "- %s\n", n)
+		}
+		b.WriteString("\n")
+	}
+
+	for i, pd := range info.ProofDelegations {
+		writeDelegationMarkdown(b, pd, fmt.Sprintf("Proof Delegation %d", i+1), level+1)
+	}
+}
+
+// formatDelegationAsText formats a delegation and its proofs as a compact
+// tree with one line per delegation
+func formatDelegationAsText(info *delegation.DelegationInfo) string {
+	var b strings.Builder
+	b.WriteString(formatDelegationLine(info))
+	writeProofLines(&b, info, "")
+	return b.String()
+}
+
+func writeProofLines(b *strings.Builder, info *delegation.DelegationInfo, prefix string) {
+	notes := proofNotes(info)
+	for i, pd := range info.ProofDelegations {
+		branch, indent := "├── ", "│   "
+		if i == len(info.ProofDelegations)-1 && len(notes) == 0 {
+			branch, indent = "└── ", "    "
+		}
+		b.WriteString("\n" + prefix + branch + formatDelegationLine(pd))
+		writeProofLines(b, pd, prefix+indent)
+	}
+	for i, n := range notes {
+		branch := "├── "
+		if i == len(notes)-1 {
+			branch = "└── "
+		}
+		b.WriteString("\n" + prefix + branch + n)
+	}
+}
+
+// proofNotes describes the proofs of a delegation that were not inspected
+func proofNotes(info *delegation.DelegationInfo) []string {
+	var notes []string
+	for _, cid := range info.DuplicateProofs {
+		notes = append(notes, fmt.Sprintf("(duplicate) %s, shown elsewhere in the chain", cid))
+	}
+	for _, cid := range info.ExternalProofs {
+		notes = append(notes, fmt.Sprintf("(external) %s, resolved from --proofs-dir or --proof", cid))
+	}
+	for _, cid := range info.MissingProofs {
+		notes = append(notes, fmt.Sprintf("(missing) %s, not included in archive", cid))
+	}
+	for _, e := range info.ProofErrors {
+		notes = append(notes, fmt.Sprintf("(error) %s", e))
+	}
+	if info.Truncated {
+		notes = append(notes, "(truncated) proofs not inspected beyond --max-depth")
+	}
+	return notes
+}
+
+func formatDelegationLine(info *delegation.DelegationInfo) string {
+	abilities := make([]string, 0, len(info.Capabilities))
+	for _, c := range info.Capabilities {
+		abilities = append(abilities, c.Can)
+	}
+	expiry := formatExpiry(info.Expiration)
+	if info.Expiration != nil {
+		expiry = "expires " + expiry
+	}
+	line := fmt.Sprintf("%s -> %s [%s] %s (%s)", info.Issuer, info.Audience, strings.Join(abilities, ", "), expiry, info.CID)
+	if info.Status != "" && info.Status != delegation.StatusValid {
+		line += " " + strings.ToUpper(string(info.Status))
+	}
+	return line
+}
+
+// formatExpiry renders an expiration timestamp as RFC3339, or "no expiry"
+func formatExpiry(exp *int) string {
+	if exp == nil {
+		return "no expiry"
+	}
+	return time.Unix(int64(*exp), 0).UTC().Format(time.RFC3339)
+}
@@ -6,31 +6,28 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
-	"github.com/storacha/go-libstoracha/capabilities/assert"
-	"github.com/storacha/go-libstoracha/capabilities/blob"
-	"github.com/storacha/go-libstoracha/capabilities/claim"
-	"github.com/storacha/go-libstoracha/capabilities/http"
-	"github.com/storacha/go-libstoracha/capabilities/pdp"
-	spaceblob "github.com/storacha/go-libstoracha/capabilities/space/blob"
-	"github.com/storacha/go-libstoracha/capabilities/ucan"
-	"github.com/storacha/go-ucanto/core/delegation"
 	"github.com/storacha/go-ucanto/did"
-	"github.com/storacha/go-ucanto/principal"
-	"github.com/storacha/go-ucanto/principal/signer"
+	ucanto "github.com/storacha/go-ucanto/ucan"
 
 	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
 )
 
 var (
 	// Gen command flags
 	issuerPrivateKey         string
+	issuerKeyURI             string
 	issuerDidWebKey          string
 	audienceDidKey           string
 	capabilities             []string
 	skipCapabilityValidation bool
 	expiration               int64
+	recordInLedger           bool
+	dryRun                   bool
+	dryRunFormat             string
+	issuerDidKey             string
+	unsignedOut              string
 )
 
 // genCmd represents the gen command
@@ -45,8 +42,11 @@ var genCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(genCmd)
 
-	genCmd.Flags().StringVarP(&issuerPrivateKey, "issuer-private-key", "i", "", "Path to PEM encoded Ed25519 private key of delegation issuer")
-	Must(genCmd.MarkFlagRequired("issuer-private-key"))
+	genCmd.Flags().StringVarP(&issuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of delegation issuer")
+	genCmd.Flags().StringVar(&issuerDidKey, "issuer-did-key", "", "did:key of delegation issuer, used instead of the private key with --unsigned-out or --dry-run")
+	genCmd.Flags().StringVar(&issuerKeyURI, "issuer", "", "URI of the delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	genCmd.MarkFlagsOneRequired("issuer-private-key", "issuer-did-key", "issuer")
+	genCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer-did-key", "issuer")
 
 	genCmd.Flags().StringVarP(&issuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, when provided warps did:key: of delegation issuer")
 
@@ -57,27 +57,19 @@ func init() {
 	Must(genCmd.MarkFlagRequired("capabilities"))
 	genCmd.Flags().BoolVarP(&skipCapabilityValidation, "skip-capability-validation", "s", false, "when set skips validation of capabilities against known set of capabilities")
 	genCmd.Flags().Int64VarP(&expiration, "expiration", "e", 0, "expiration time in UTC seconds since Unix\n// epoch")
+	genCmd.Flags().BoolVar(&recordInLedger, "record", false, "record the delegation in the local issuance ledger")
+	genCmd.Flags().BoolVar(&dryRun, "dry-run", false, "display the delegation that would be issued without signing it")
+	genCmd.Flags().StringVarP(&dryRunFormat, "format", "f", "table", "Output format of --dry-run: table, json, yaml, markdown, text, dot or mermaid")
+	genCmd.Flags().StringVar(&unsignedOut, "unsigned-out", "", "write the unsigned UCAN payload to this file for offline signing instead of signing the delegation")
+	genCmd.MarkFlagsMutuallyExclusive("dry-run", "unsigned-out")
 }
 
 func mkDelegation(cmd *cobra.Command, args []string) error {
-	issuer, err := parseIssuerKey(issuerPrivateKey)
+	issuer, err := genIssuer()
 	if err != nil {
-		return fmt.Errorf("parsing issuer private key from file %s: %w", issuerPrivateKey, err)
-	}
-
-	if issuerDidWebKey != "" {
-		if !strings.HasPrefix(issuerDidWebKey, "did:web:") {
-			return fmt.Errorf("issuer did:web: must start with 'did:web:' prefix")
-		}
-		issuerDidWeb, err := did.Parse(issuerDidWebKey)
-		if err != nil {
-			return fmt.Errorf("parsing issuer did web key (%s): %w", issuerDidWebKey, err)
-		}
-		issuer, err = signer.Wrap(issuer, issuerDidWeb)
-		if err != nil {
-			return fmt.Errorf("wrapping issuer with did web key (%s): %w", issuerDidWebKey, err)
-		}
+		return err
 	}
+	defer keys.Close(issuer)
 
 	audience, err := did.Parse(audienceDidKey)
 	if err != nil {
@@ -85,7 +77,7 @@ func mkDelegation(cmd *cobra.Command, args []string) error {
 	}
 
 	if !skipCapabilityValidation {
-		if err := validateCapability(capabilities); err != nil {
+		if err := mkd.ValidateCapabilities(capabilities); err != nil {
 			// TODO consider returning the list of known capabilities in the event of a failure for more discoverable UX
 			// alternatively, there could be a `list capabilities` command that allows you to list the set of known
 			// capabilities validation will be performed against
@@ -93,69 +85,105 @@ func mkDelegation(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	var opts []delegation.Option
-	if expiration > 0 {
-		if time.Now().Unix() > expiration {
-			return fmt.Errorf("provided expiration time %d is in the past", expiration)
-		}
-		opts = append(opts, delegation.WithExpiration(int(expiration)))
-	} else {
-		opts = append(opts, delegation.WithNoExpiration())
+	if dryRun {
+		return dryRunDelegation(cmd, issuer, audience)
 	}
 
-	d, err := mkd.MakeDelegation(issuer, audience, capabilities, opts...)
+	checked, err := policyIssuer(issuer)
+	if err != nil {
+		return err
+	}
+	d, err := genBuilder(checked, audience).Build()
 	if err != nil {
 		return fmt.Errorf("making delegation: %w", err)
 	}
 
+	if unsignedOut != "" {
+		payload, err := mkd.EncodePayload(d)
+		if err != nil {
+			return fmt.Errorf("encoding unsigned payload: %w", err)
+		}
+		if err := os.WriteFile(unsignedOut, payload, 0o644); err != nil {
+			return fmt.Errorf("writing unsigned payload: %w", err)
+		}
+		cmd.PrintErrf("Wrote unsigned payload to %s, sign it with: mkdelegation sign %s -i <key.pem>\n", unsignedOut, unsignedOut)
+		return nil
+	}
+
 	out, err := mkd.FormatDelegation(d.Archive())
 	if err != nil {
 		return fmt.Errorf("formatting delegation: %w", err)
 	}
+
+	if recordInLedger {
+		if err := recordDelegation(d); err != nil {
+			return err
+		}
+	}
+
 	fmt.Println(out)
 	return nil
 }
 
-// KnownCapabilities is the set of known storacha service capabilities for a delegation
-// TODO: define a set in go-libstoracha that can be updated independently of this
-var KnownCapabilities = map[string]bool{
-	assert.EqualsAbility:       true,
-	assert.RelationAbility:     true,
-	assert.PartitionAbility:    true,
-	assert.IndexAbility:        true,
-	assert.InclusionAbility:    true,
-	assert.LocationAbility:     true,
-	blob.AcceptAbility:         true,
-	blob.AllocateAbility:       true,
-	claim.CacheAbility:         true,
-	http.PutAbility:            true,
-	pdp.AcceptAbility:          true,
-	pdp.InfoAbility:            true,
-	spaceblob.AddAbility:       true,
-	spaceblob.GetAbility:       true,
-	spaceblob.ListAbility:      true,
-	spaceblob.RemoveAbility:    true,
-	spaceblob.ReplicateAbility: true,
-	ucan.ConcludeAbility:       true,
-}
+// genIssuer returns the issuer of the generated delegation. Without signing,
+// for --unsigned-out and --dry-run, the issuer private key may be replaced by
+// its did:key.
+func genIssuer() (ucanto.Signer, error) {
+	if issuerDidKey == "" {
+		issuer, err := loadSigner(issuerPrivateKey, issuerKeyURI, issuerDidWebKey)
+		if err != nil {
+			return nil, err
+		}
+		if unsignedOut != "" {
+			defer keys.Close(issuer)
+			return mkd.UnsignedIssuer(issuer.DID()), nil
+		}
+		return issuer, nil
+	}
+	if unsignedOut == "" && !dryRun {
+		return nil, fmt.Errorf("--issuer-did-key can only be used with --unsigned-out or --dry-run")
+	}
 
-func validateCapability(capabilities []string) error {
-	var errs error
-	for _, capability := range capabilities {
-		if !KnownCapabilities[capability] {
-			errs = multierror.Append(errs, fmt.Errorf("unknown capability: %s", capability))
+	id := issuerDidKey
+	if !strings.HasPrefix(id, "did:key:") {
+		return nil, fmt.Errorf("issuer did:key: must start with 'did:key:' prefix")
+	}
+	if issuerDidWebKey != "" {
+		if !strings.HasPrefix(issuerDidWebKey, "did:web:") {
+			return nil, fmt.Errorf("issuer did:web: must start with 'did:web:' prefix")
 		}
+		id = issuerDidWebKey
 	}
-	return errs
+	issuer, err := did.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("parsing issuer did (%s): %w", id, err)
+	}
+	return mkd.UnsignedIssuer(issuer), nil
+}
+
+// genBuilder returns a builder of the delegation of the capabilities on the
+// issuer resource, which never expires unless --expiration is provided
+func genBuilder(issuer ucanto.Signer, audience did.DID) *mkd.Builder {
+	b := mkd.New(issuer).To(audience)
+	for _, c := range capabilities {
+		b.Grant(c, issuer.DID().String(), nil)
+	}
+	if expiration > 0 {
+		b.ExpiresAt(time.Unix(expiration, 0))
+	}
+	return b
 }
 
-// parseIssuerKey attempts to read and parse the private key from the
-// provided path.
-func parseIssuerKey(path string) (principal.Signer, error) {
-	f, err := os.Open(path)
+// dryRunDelegation builds the delegation with an unsigned issuer and displays
+// it as parse would, then reports any policy violation
+func dryRunDelegation(cmd *cobra.Command, issuer ucanto.Signer, audience did.DID) error {
+	d, err := genBuilder(mkd.UnsignedIssuer(issuer.DID()), audience).Build()
 	if err != nil {
-		return nil, fmt.Errorf("opening file: %w", err)
+		return fmt.Errorf("making delegation: %w", err)
+	}
+
+	if err := printDelegationInfo(cmd, mkd.Inspect(d), dryRunFormat); err != nil {
+		return err
 	}
-	defer f.Close()
-	return parsePrivateKeyPEM(f)
+	return enforcePolicy(d)
 }
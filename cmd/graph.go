@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// delegationGraph is the proof chain of a delegation as a graph whose nodes are
+// principals and whose edges are delegations from issuer to audience
+type delegationGraph struct {
+	nodes []string
+	ids   map[string]string
+	edges []graphEdge
+}
+
+type graphEdge struct {
+	from      string
+	to        string
+	abilities []string
+	expiry    string
+}
+
+// newDelegationGraph builds the graph of a delegation and its proofs,
+// including each delegation only once even when it is repeated in the chain
+func newDelegationGraph(info *delegation.DelegationInfo) *delegationGraph {
+	g := &delegationGraph{ids: map[string]string{}}
+	g.add(info, map[string]bool{})
+	return g
+}
+
+func (g *delegationGraph) add(info *delegation.DelegationInfo, seen map[string]bool) {
+	if info.CID != "" {
+		if seen[info.CID] {
+			return
+		}
+		seen[info.CID] = true
+	}
+
+	var abilities []string
+	for _, c := range info.Capabilities {
+		abilities = append(abilities, c.Can)
+	}
+	expiry := "no expiry"
+	if info.Expiration != nil {
+		expiry = "exp " + time.Unix(int64(*info.Expiration), 0).UTC().Format(time.RFC822)
+	}
+	g.edges = append(g.edges, graphEdge{
+		from:      g.node(info.Issuer),
+		to:        g.node(info.Audience),
+		abilities: abilities,
+		expiry:    expiry,
+	})
+
+	for _, pd := range info.ProofDelegations {
+		g.add(pd, seen)
+	}
+}
+
+// node returns the identifier of the node for a principal, adding it to the
+// graph when it is seen for the first time
+func (g *delegationGraph) node(principal string) string {
+	if id, ok := g.ids[principal]; ok {
+		return id
+	}
+	id := fmt.Sprintf("n%d", len(g.nodes))
+	g.ids[principal] = id
+	g.nodes = append(g.nodes, principal)
+	return id
+}
+
+// formatDelegationAsDot renders the proof chain of a delegation in Graphviz DOT format
+func formatDelegationAsDot(info *delegation.DelegationInfo) string {
+	g := newDelegationGraph(info)
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph delegations {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, principal := range g.nodes {
+		b.WriteString(fmt.Sprintf("  %s [label=%s];\n", g.ids[principal], quote(principal)))
+	}
+	for _, e := range g.edges {
+		label := strings.Join(append(e.abilities, e.expiry), `\n`)
+		b.WriteString(fmt.Sprintf("  %s -> %s [label=%s];\n", e.from, e.to, quote(label)))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// formatDelegationAsMermaid renders the proof chain of a delegation as a Mermaid flowchart
+func formatDelegationAsMermaid(info *delegation.DelegationInfo) string {
+	g := newDelegationGraph(info)
+	quote := func(s string) string {
+		return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+	}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for _, principal := range g.nodes {
+		b.WriteString(fmt.Sprintf("  %s[%s]\n", g.ids[principal], quote(principal)))
+	}
+	for _, e := range g.edges {
+		label := strings.Join(append(e.abilities, e.expiry), "<br/>")
+		b.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", e.from, quote(label), e.to))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+)
+
+var (
+	// Key command flags
+	keyConvertFrom string
+	keyConvertTo   string
+	keyConvertOut  string
+)
+
+// keyCmd represents the key command
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Manage Ed25519 private keys",
+	Long: `Ed25519 private keys are accepted in the following formats wherever a key file is expected:
+   pem (PKCS#8, as written by openssl), openssh (unencrypted, as written by ssh-keygen), jwk (OKP/Ed25519),
+   seed-hex and seed-base64 (the raw 32-byte seed) and multibase (as formatted by ucanto).`,
+}
+
+var keyConvertCmd = &cobra.Command{
+	Use:   "convert [KEY_FILE]",
+	Short: "Convert a private key between formats",
+	Long: `Converts an Ed25519 private key read from a file or stdin if no file is provided to another format. The
+   input format is detected unless --from is provided. The did:key of the key is printed on stderr.
+   Examples:
+     - PEM to JWK: mkdelegation key convert --to jwk key.pem
+     - OpenSSH to PEM: mkdelegation key convert --to pem -o key.pem ~/.ssh/id_ed25519
+     - Hex seed to multibase: echo 9d61b19d... | mkdelegation key convert --from seed-hex --to multibase`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         convertKey,
+}
+
+func init() {
+	rootCmd.AddCommand(keyCmd)
+	keyCmd.AddCommand(keyConvertCmd)
+
+	keyConvertCmd.Flags().StringVar(&keyConvertFrom, "from", string(keys.FormatAuto), "Format of the input key: auto, pem, openssh, jwk, seed-hex, seed-base64 or multibase")
+	keyConvertCmd.Flags().StringVar(&keyConvertTo, "to", "", "Format of the output key: pem, openssh, jwk, seed-hex, seed-base64 or multibase")
+	Must(keyConvertCmd.MarkFlagRequired("to"))
+	keyConvertCmd.Flags().StringVarP(&keyConvertOut, "out", "o", "", "File to write the converted key to, stdout when not provided")
+}
+
+func convertKey(cmd *cobra.Command, args []string) error {
+	var data []byte
+	var err error
+	if len(args) >= 1 && args[0] != "-" {
+		data, err = os.ReadFile(args[0])
+	} else {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	}
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+
+	key, err := keys.Decode(data, keys.Format(keyConvertFrom))
+	if err != nil {
+		return err
+	}
+	out, err := keys.Encode(key, keys.Format(keyConvertTo))
+	if err != nil {
+		return err
+	}
+
+	cmd.PrintErrf("Key %s\n", key.DID())
+	if keyConvertOut == "" {
+		_, err := cmd.OutOrStdout().Write(out)
+		return err
+	}
+	// the output holds a private key
+	if err := os.WriteFile(keyConvertOut, out, 0o600); err != nil {
+		return fmt.Errorf("writing key: %w", err)
+	}
+	return nil
+}
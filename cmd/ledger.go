@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/core/delegation"
+
+	"github.com/storacha/go-mkdelegation/pkg/ledger"
+)
+
+var (
+	// Ledger command flags
+	ledgerJsonOutput bool
+	ledgerExportDir  string
+)
+
+// ledgerCmd represents the ledger command
+var ledgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Query the local ledger of issued delegations",
+	Long: `Queries the local issuance ledger, in which delegations generated with gen --record are recorded.
+   Examples:
+     - List recorded delegations: mkdelegation ledger list
+     - Show a delegation: mkdelegation ledger show bafyrei...
+     - Search by DID or ability: mkdelegation ledger search blob/allocate
+     - Export each delegation to a file: mkdelegation ledger export --dir ./delegations`,
+}
+
+var ledgerListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the recorded delegations",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := openLedger()
+		if err != nil {
+			return err
+		}
+		entries, err := l.List()
+		if err != nil {
+			return err
+		}
+		return printLedgerEntries(cmd, entries)
+	},
+}
+
+var ledgerShowCmd = &cobra.Command{
+	Use:          "show CID",
+	Short:        "Show a recorded delegation",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := openLedger()
+		if err != nil {
+			return err
+		}
+		e, ok, err := l.Get(args[0])
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("delegation %s not found in ledger %s", args[0], l.Path())
+		}
+
+		if ledgerJsonOutput {
+			return printJSON(cmd, e)
+		}
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "CID:        %s\n", e.CID)
+		fmt.Fprintf(out, "Issuer:     %s\n", e.Issuer)
+		fmt.Fprintf(out, "Audience:   %s\n", e.Audience)
+		fmt.Fprintf(out, "Abilities:  %s\n", strings.Join(e.Abilities, ", "))
+		fmt.Fprintf(out, "Expiration: %s\n", formatExpiry(e.Expiration))
+		fmt.Fprintf(out, "Issued At:  %s\n", e.IssuedAt.Format(time.RFC3339))
+		fmt.Fprintf(out, "Delegation: %s\n", e.Delegation)
+		return nil
+	},
+}
+
+var ledgerSearchCmd = &cobra.Command{
+	Use:          "search TERM",
+	Short:        "Search recorded delegations by CID, issuer, audience or ability",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := openLedger()
+		if err != nil {
+			return err
+		}
+		entries, err := l.Search(args[0])
+		if err != nil {
+			return err
+		}
+		return printLedgerEntries(cmd, entries)
+	},
+}
+
+var ledgerExportCmd = &cobra.Command{
+	Use:          "export",
+	Short:        "Export the recorded delegations as JSON, or as one file per delegation",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l, err := openLedger()
+		if err != nil {
+			return err
+		}
+		entries, err := l.List()
+		if err != nil {
+			return err
+		}
+
+		if ledgerExportDir == "" {
+			if entries == nil {
+				entries = []ledger.Entry{}
+			}
+			return printJSON(cmd, entries)
+		}
+
+		if err := os.MkdirAll(ledgerExportDir, 0o755); err != nil {
+			return fmt.Errorf("creating export directory: %w", err)
+		}
+		for _, e := range entries {
+			path := filepath.Join(ledgerExportDir, e.CID+".b64")
+			if err := os.WriteFile(path, []byte(e.Delegation+"\n"), 0o644); err != nil {
+				return fmt.Errorf("writing delegation %s: %w", e.CID, err)
+			}
+		}
+		cmd.PrintErrf("Exported %d delegation(s) to %s\n", len(entries), ledgerExportDir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ledgerCmd)
+	ledgerCmd.AddCommand(ledgerListCmd, ledgerShowCmd, ledgerSearchCmd, ledgerExportCmd)
+
+	ledgerCmd.PersistentFlags().BoolVarP(&ledgerJsonOutput, "json", "j", false, "Output in JSON format")
+	ledgerExportCmd.Flags().StringVar(&ledgerExportDir, "dir", "", "Directory to write each delegation to as <CID>.b64")
+}
+
+// openLedger opens the ledger at the configured path, or at the default path
+// in the user config directory
+func openLedger() (*ledger.Ledger, error) {
+	path := ledgerPath
+	if path == "" {
+		var err error
+		path, err = ledger.DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ledger.Open(path)
+}
+
+// recordDelegation records a newly issued delegation in the ledger
+func recordDelegation(d delegation.Delegation) error {
+	l, err := openLedger()
+	if err != nil {
+		return err
+	}
+	e, err := ledger.NewEntry(d, time.Now())
+	if err != nil {
+		return fmt.Errorf("creating ledger entry: %w", err)
+	}
+	if err := l.Record(e); err != nil {
+		return fmt.Errorf("recording delegation in ledger %s: %w", l.Path(), err)
+	}
+	return nil
+}
+
+func printLedgerEntries(cmd *cobra.Command, entries []ledger.Entry) error {
+	if ledgerJsonOutput {
+		if entries == nil {
+			entries = []ledger.Entry{}
+		}
+		return printJSON(cmd, entries)
+	}
+
+	tableString := &strings.Builder{}
+	table := tablewriter.NewWriter(tableString)
+	table.SetHeader([]string{"CID", "Issued At", "Issuer", "Audience", "Abilities", "Expiration"})
+	table.SetAutoWrapText(false)
+	table.SetRowLine(true)
+	for _, e := range entries {
+		table.Append([]string{
+			e.CID,
+			e.IssuedAt.Format(time.RFC3339),
+			e.Issuer,
+			e.Audience,
+			strings.Join(e.Abilities, "\n"),
+			formatExpiry(e.Expiration),
+		})
+	}
+	table.Render()
+	fmt.Fprint(cmd.OutOrStdout(), tableString.String())
+	return nil
+}
+
+func printJSON(cmd *cobra.Command, v any) error {
+	jsonOutput, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(jsonOutput))
+	return nil
+}
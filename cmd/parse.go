@@ -14,11 +14,18 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
 )
 
 var (
 	// Parse command flags
-	parseJsonOutput bool
+	parseJsonOutput          bool
+	parseFormat              string
+	parseWarnWithin          string
+	parseFailIfExpiresWithin string
+	parseMaxDepth            int
+	parseProofsDir           string
+	parseProofFiles          []string
 )
 
 // parseCmd represents the parse command
@@ -26,11 +33,18 @@ var parseCmd = &cobra.Command{
 	Use:     "parse [DELEGATION_FILE]",
 	Aliases: []string{"p"},
 	Short:   "Parse and display information about a UCAN delegation from a file or stdin",
-	Long: `Parses a UCAN delegation from a file or stdin if no file is provided.
+	Long: `Parses a UCAN delegation from a file or stdin if no file is provided. Each delegation of a bundle is
+   reported in turn, and as a JSON array with --json.
    Examples:
      - Parse from file: mkdelegation parse delegation.b64
      - Parse from stdin: cat delegation.b64 | mkdelegation parse
-     - Parse directly: echo 'base64content' | mkdelegation parse`,
+     - Parse directly: echo 'base64content' | mkdelegation parse
+     - Fail when the chain expires within a week: mkdelegation parse --fail-if-expires-within 7d delegation.b64
+     - Compact one line per delegation view: mkdelegation parse --format text delegation.b64
+     - Render the proof chain as a graph: mkdelegation parse --format mermaid delegation.b64
+     - Only inspect the direct proofs: mkdelegation parse --max-depth 1 delegation.b64
+     - Parse every delegation of a bundle: mkdelegation parse --format text bundle.car
+     - Resolve proofs missing from the archive: mkdelegation parse --proofs-dir ./delegations --proof root.b64 delegation.b64`,
 	Args:         cobra.MaximumNArgs(1),
 	SilenceUsage: true,
 	RunE:         parseDelegation,
@@ -39,14 +53,26 @@ var parseCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(parseCmd)
 
-	parseCmd.Flags().BoolVarP(&parseJsonOutput, "json", "j", false, "Output in JSON format")
+	parseCmd.Flags().BoolVarP(&parseJsonOutput, "json", "j", false, "Output in JSON format (shorthand for --format json)")
+	parseCmd.Flags().StringVarP(&parseFormat, "format", "f", "table", "Output format: table, json, yaml, markdown, text, dot or mermaid")
+	parseCmd.Flags().StringVar(&parseWarnWithin, "warn-within", "7d", "Report delegations expiring within this duration as expiring (e.g. 72h, 7d)")
+	parseCmd.Flags().IntVar(&parseMaxDepth, "max-depth", delegation.DefaultMaxDepth, "Maximum depth of the proof chain inspected, negative for no limit")
+	parseCmd.Flags().StringVar(&parseProofsDir, "proofs-dir", "", "Directory of delegation files to resolve proofs missing from the archive from")
+	parseCmd.Flags().StringArrayVar(&parseProofFiles, "proof", nil, "Delegation file to resolve proofs missing from the archive from (repeatable)")
+	parseCmd.Flags().StringVar(&parseFailIfExpiresWithin, "fail-if-expires-within", "", "Exit with a non-zero status if the delegation chain expires within this duration (e.g. 7d)")
 }
 
-// parseDelegation reads a delegation from a file or stdin and displays its information
+// parseDelegation reads a delegation, or a bundle of delegations, from a file or stdin and displays its
+// information
 func parseDelegation(cmd *cobra.Command, args []string) error {
-	var info *delegation.DelegationInfo
+	var data []byte
 	var err error
 
+	sources, err := loadProofSources(cmd, parseProofsDir, parseProofFiles)
+	if err != nil {
+		return err
+	}
+
 	// Check if a file path is provided
 	if len(args) >= 1 {
 		filePath := args[0]
@@ -56,42 +82,145 @@ func parseDelegation(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("file does not exist: %s", filePath)
 		}
 
-		// Parse the delegation from file
-		info, err = delegation.ParseDelegationFromFile(filePath)
+		data, err = os.ReadFile(filePath)
 		if err != nil {
-			return fmt.Errorf("failed to parse delegation: %w", err)
+			return fmt.Errorf("failed to read delegation file: %w", err)
 		}
 	} else {
 		// No file provided, read from stdin
-		stdinData, err := io.ReadAll(os.Stdin)
+		data, err = io.ReadAll(os.Stdin)
 		if err != nil {
 			return fmt.Errorf("failed to read from stdin: %w", err)
 		}
 
-		if len(stdinData) == 0 {
+		if len(data) == 0 {
 			return fmt.Errorf("no input provided via stdin and no file specified")
 		}
+	}
 
-		// Parse the delegation from stdin content
-		info, err = delegation.ParseDelegationContent(string(stdinData))
+	// Parse the delegation, or the delegations of a bundle
+	ds, err := delegation.DecodeBundleBytes(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse delegation: %w", err)
+	}
+
+	warnWithin, err := policy.ParseDuration(parseWarnWithin)
+	if err != nil {
+		return fmt.Errorf("parsing warn within: %w", err)
+	}
+	var failWithin time.Duration
+	if parseFailIfExpiresWithin != "" {
+		failWithin, err = policy.ParseDuration(parseFailIfExpiresWithin)
 		if err != nil {
-			return fmt.Errorf("failed to parse delegation from stdin: %w", err)
+			return fmt.Errorf("parsing fail if expires within: %w", err)
 		}
 	}
 
-	// Output as JSON if requested
+	now := time.Now()
+	infos := make([]*delegation.DelegationInfo, 0, len(ds))
+	for _, d := range ds {
+		info := delegation.Inspect(d, delegation.WithMaxDepth(parseMaxDepth), delegation.WithProofSources(sources...))
+		delegation.AnnotateExpiry(info, now, warnWithin)
+		infos = append(infos, info)
+	}
+
+	format := parseFormat
 	if parseJsonOutput {
-		jsonOutput, err := json.MarshalIndent(info, "", "  ")
+		format = "json"
+	}
+	if err := printBundleInfo(cmd, infos, format); err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if unresolved := delegation.UnresolvedProofs(info); len(unresolved) > 0 {
+			cmd.PrintErrf("Warning: delegation chain of %s is incomplete, unresolved proofs: %s (supply them with --proofs-dir or --proof)\n", info.CID, strings.Join(unresolved, ", "))
+		}
+	}
+
+	if parseFailIfExpiresWithin != "" {
+		for _, info := range infos {
+			if exp := info.EffectiveExpiration; exp != nil && time.Unix(int64(*exp), 0).Sub(now) <= failWithin {
+				if len(infos) > 1 {
+					return fmt.Errorf("delegation chain of %s expires at %s, within %s", info.CID, formatExpiry(exp), parseFailIfExpiresWithin)
+				}
+				return fmt.Errorf("delegation chain expires at %s, within %s", formatExpiry(exp), parseFailIfExpiresWithin)
+			}
+		}
+	}
+
+	return nil
+}
+
+// printBundleInfo outputs the information of the delegations of a bundle in the requested format, as a JSON
+// array or one after the other with a header line. A single delegation is output as printDelegationInfo does.
+func printBundleInfo(cmd *cobra.Command, infos []*delegation.DelegationInfo, format string) error {
+	if len(infos) == 1 {
+		return printDelegationInfo(cmd, infos[0], format)
+	}
+
+	out := cmd.OutOrStdout()
+	if format == "json" {
+		jsonOutput, err := json.MarshalIndent(infos, "", "  ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal delegation info to JSON: %w", err)
 		}
-		cmd.Println(string(jsonOutput))
+		fmt.Fprintln(out, string(jsonOutput))
 		return nil
 	}
 
-	// Use the formatDelegationAsTable function to format the delegation
-	cmd.Println("Delegation Information:")
-	cmd.Println(formatDelegationAsTable(info, 0))
+	for i, info := range infos {
+		title := fmt.Sprintf("Delegation %d of %d (%s)", i+1, len(infos), info.CID)
+		switch format {
+		case "yaml":
+			fmt.Fprintf(out, "---\n# %s\n", title)
+		case "markdown":
+			fmt.Fprintf(out, "# %s\n\n", title)
+		case "dot":
+			fmt.Fprintf(out, "// %s\n", title)
+		case "mermaid":
+			fmt.Fprintf(out, "%%%% %s\n", title)
+		default:
+			fmt.Fprintf(out, "=== %s ===\n", title)
+		}
+		if err := printDelegationInfo(cmd, info, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDelegationInfo outputs the delegation information in the requested format
+func printDelegationInfo(cmd *cobra.Command, info *delegation.DelegationInfo, format string) error {
+	out := cmd.OutOrStdout()
+	switch format {
+	case "json":
+		jsonOutput, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal delegation info to JSON: %w", err)
+		}
+		fmt.Fprintln(out, string(jsonOutput))
+	case "dot":
+		fmt.Fprintln(out, formatDelegationAsDot(info))
+	case "mermaid":
+		fmt.Fprintln(out, formatDelegationAsMermaid(info))
+	case "yaml":
+		yamlOutput, err := formatDelegationAsYAML(info)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, yamlOutput)
+	case "markdown":
+		fmt.Fprintln(out, formatDelegationAsMarkdown(info))
+	case "text":
+		fmt.Fprintln(out, formatDelegationAsText(info))
+	case "table":
+		// Use the formatDelegationAsTable function to format the delegation
+		fmt.Fprintln(out, "Delegation Information:")
+		fmt.Fprintln(out, formatDelegationAsTable(info, 0))
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
 
 	return nil
 }
@@ -113,13 +242,22 @@ func formatDelegationAsTable(info *delegation.DelegationInfo, depth int) string
 	table.Append([]string{"Version", info.Version})
 	table.Append([]string{"Nonce", fmt.Sprintf("%v", info.Nonce)})
 	if depth == 0 { // Only show raw proofs at top level
-		table.Append([]string{"Proofs", fmt.Sprintf("%v", info.Proofs)})
+		table.Append([]string{"Proofs", joinRaw(info.Proofs, "\n")})
 	}
-	table.Append([]string{"Signature (b64)", base64.StdEncoding.EncodeToString(info.Signature)})
+	table.Append([]string{"Signature (b64)", formatSignature(info.Signature)})
 	if info.Expiration != nil {
 		table.Append([]string{"Expiration", strconv.Itoa(*info.Expiration) + fmt.Sprintf(" (%s)", time.Unix(int64(*info.Expiration), 0).UTC().Format(time.RFC822))})
 	}
 	table.Append([]string{"Not Before", strconv.Itoa(info.NotBefore)})
+	if info.Status != "" {
+		table.Append([]string{"Status", string(info.Status)})
+	}
+	if depth == 0 && info.Incomplete {
+		table.Append([]string{"Incomplete", "proofs of the chain are missing or could not be read"})
+	}
+	if depth == 0 && info.EffectiveExpiration != nil {
+		table.Append([]string{"Effective Expiration", strconv.Itoa(*info.EffectiveExpiration) + fmt.Sprintf(" (%s)", time.Unix(int64(*info.EffectiveExpiration), 0).UTC().Format(time.RFC822))})
+	}
 
 	// Create capabilities table as a subtable
 	var capTable string
@@ -157,7 +295,7 @@ func formatDelegationAsTable(info *delegation.DelegationInfo, depth int) string
 		factTableWriter.SetColWidth(50 - (depth * 2))
 
 		for i, f := range info.Facts {
-			factTableWriter.Append([]string{fmt.Sprintf("%d", i+1), fmt.Sprintf("%v", f)})
+			factTableWriter.Append([]string{fmt.Sprintf("%d", i+1), string(f)})
 		}
 
 		factTableWriter.Render()
@@ -166,12 +304,30 @@ func formatDelegationAsTable(info *delegation.DelegationInfo, depth int) string
 		factTable = "None"
 	}
 	table.Append([]string{"Facts", factTable})
+	if len(info.EncodingErrors) > 0 {
+		table.Append([]string{"Encoding Errors", strings.Join(info.EncodingErrors, "\n")})
+	}
 
 	// Create proof delegations table recursively
 	if len(info.ProofDelegations) > 0 {
 		proofDelegsTable := formatProofDelegations(info.ProofDelegations, depth+1)
 		table.Append([]string{"Proof Delegations", proofDelegsTable})
 	}
+	if len(info.DuplicateProofs) > 0 {
+		table.Append([]string{"Duplicate Proofs", strings.Join(info.DuplicateProofs, "\n")})
+	}
+	if len(info.ExternalProofs) > 0 {
+		table.Append([]string{"External Proofs", strings.Join(info.ExternalProofs, "\n") + "\n(resolved from --proofs-dir or --proof)"})
+	}
+	if len(info.MissingProofs) > 0 {
+		table.Append([]string{"Missing Proofs", strings.Join(info.MissingProofs, "\n") + "\n(not included in archive)"})
+	}
+	if len(info.ProofErrors) > 0 {
+		table.Append([]string{"Proof Errors", strings.Join(info.ProofErrors, "\n")})
+	}
+	if info.Truncated {
+		table.Append([]string{"Truncated", "proofs not inspected beyond --max-depth"})
+	}
 
 	table.Render()
 	return tableString.String()
@@ -196,3 +352,21 @@ func formatProofDelegations(proofDelegations []*delegation.DelegationInfo, depth
 
 	return result.String()
 }
+
+// formatSignature returns the base64 encoded signature, or a marker for
+// delegations built without signing them
+func formatSignature(sig []byte) string {
+	if len(sig) == 0 {
+		return "(unsigned)"
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+// joinRaw joins DAG-JSON encoded values with the provided separator
+func joinRaw(values []json.RawMessage, sep string) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, string(v))
+	}
+	return strings.Join(strs, sep)
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/ucan"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+var (
+	// Policy command flags
+	policyJsonOutput bool
+)
+
+// policyCmd represents the policy command
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Check delegations against an issuance policy",
+	Long: `An issuance policy is a YAML file restricting the delegations gen, attenuate, renew, rotate, sign, assemble and
+   serve may issue when it is passed with --policy: which audiences may receive which abilities, the maximum validity, whether wildcard abilities are
+   forbidden and which facts are mandatory.
+   Example policy:
+     grants:
+       - audiences: ["did:key:z6Mkh..."]
+         abilities: ["blob/*"]
+       - audiences: ["*"]
+         abilities: ["claim/cache"]
+     maxExpiry: 90d
+     forbidWildcards: true
+     requiredFacts: ["purpose"]`,
+}
+
+var policyCheckCmd = &cobra.Command{
+	Use:   "check [DELEGATION_FILE]",
+	Short: "Check a delegation against the policy",
+	Long: `Checks a delegation from a file or stdin against the policy passed with --policy, as if it were issued now,
+   and reports the rules it violates.
+   Examples:
+     - Check a delegation: mkdelegation policy check --policy policy.yaml delegation.b64`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         checkPolicy,
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyCheckCmd)
+
+	policyCheckCmd.Flags().BoolVarP(&policyJsonOutput, "json", "j", false, "Output in JSON format")
+}
+
+func checkPolicy(cmd *cobra.Command, args []string) error {
+	if policyPath == "" {
+		return fmt.Errorf("no policy provided, pass --policy")
+	}
+	p, err := policy.Load(policyPath)
+	if err != nil {
+		return err
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	d, err := loadDelegationArg(path)
+	if err != nil {
+		return err
+	}
+
+	violations := p.Check(d, time.Now())
+	if policyJsonOutput {
+		if violations == nil {
+			violations = []policy.Violation{}
+		}
+		jsonOutput, err := json.MarshalIndent(violations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal violations to JSON: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(jsonOutput))
+	} else if len(violations) == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s complies with the policy\n", d.Link())
+	} else {
+		for _, v := range violations {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", v.Rule, v.Message)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("delegation violates %d policy rule(s)", len(violations))
+	}
+	return nil
+}
+
+// loadPolicy loads the policy passed with --policy, or returns nil when none
+// is passed
+func loadPolicy() (*policy.Policy, error) {
+	if policyPath == "" {
+		return nil, nil
+	}
+	return policy.Load(policyPath)
+}
+
+// enforcePolicy refuses a delegation, or the payload of a delegation, that
+// violates the policy passed with --policy, if any
+func enforcePolicy(d ucan.UCAN) error {
+	p, err := loadPolicy()
+	if err != nil || p == nil {
+		return err
+	}
+	return enforceWith(p, d)
+}
+
+// policyIssuer returns the issuer, refusing to sign the delegations that
+// violate the policy passed with --policy, if any: their payload is checked
+// before the issuer is asked for a signature
+func policyIssuer(issuer ucan.Signer) (ucan.Signer, error) {
+	p, err := loadPolicy()
+	if err != nil || p == nil {
+		return issuer, err
+	}
+	return mkd.CheckingIssuer(issuer, func(payload ucan.UCAN) error {
+		return enforceWith(p, payload)
+	}), nil
+}
+
+// enforceWith enforces the policy on a delegation or the payload of one
+func enforceWith(p *policy.Policy, d ucan.UCAN) error {
+	if err := p.Enforce(d, time.Now()); err != nil {
+		return fmt.Errorf("delegation violates policy %s: %w", policyPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+var (
+	// Renew command flags
+	renewIssuerPrivateKey string
+	renewIssuerKeyURI     string
+	renewIssuerDidWebKey  string
+	renewExpiresIn        string
+	renewRecord           bool
+)
+
+// renewCmd represents the renew command
+var renewCmd = &cobra.Command{
+	Use:   "renew [DELEGATION_FILE]",
+	Short: "Renew a delegation with new time bounds",
+	Long: `Reads an existing delegation from a file or stdin, verifies that the issuer private key signed it, and
+   issues an otherwise identical delegation (same audience, capabilities, caveats, facts and proofs) that is valid
+   from now until --expires-in from now. Delegations issued by a did:web are renewed under the same did:web.
+   Examples:
+     - Renew for 90 days: mkdelegation renew old.b64 -i key.pem --expires-in 90d
+     - Renew from stdin: cat old.b64 | mkdelegation renew -i key.pem --expires-in 720h
+     - Renew with a key held by ssh-agent: mkdelegation renew old.b64 --issuer ssh-agent: --expires-in 90d`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         renewDelegation,
+}
+
+func init() {
+	rootCmd.AddCommand(renewCmd)
+
+	renewCmd.Flags().StringVarP(&renewIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of delegation issuer")
+	renewCmd.Flags().StringVar(&renewIssuerKeyURI, "issuer", "", "URI of the delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	renewCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	renewCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	renewCmd.Flags().StringVarP(&renewIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, defaults to the did:web: issuer of the delegation")
+
+	renewCmd.Flags().StringVar(&renewExpiresIn, "expires-in", "", "duration after which the renewed delegation expires (e.g. 24h, 90d)")
+	Must(renewCmd.MarkFlagRequired("expires-in"))
+
+	renewCmd.Flags().BoolVar(&renewRecord, "record", false, "record the renewed delegation in the local issuance ledger")
+}
+
+func renewDelegation(cmd *cobra.Command, args []string) error {
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	old, err := loadDelegationArg(path)
+	if err != nil {
+		return err
+	}
+
+	expiresIn, err := policy.ParseDuration(renewExpiresIn)
+	if err != nil {
+		return fmt.Errorf("parsing expires in: %w", err)
+	}
+
+	key, err := openIssuerKey(renewIssuerPrivateKey, renewIssuerKeyURI)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(key)
+	issuer, err := reissuingIssuer(key, renewIssuerDidWebKey, old)
+	if err != nil {
+		return err
+	}
+	if issuer, err = policyIssuer(issuer); err != nil {
+		return err
+	}
+
+	d, err := mkd.Renew(issuer, old, int(time.Now().Add(expiresIn).Unix()))
+	if err != nil {
+		return fmt.Errorf("renewing delegation: %w", err)
+	}
+
+	out, err := mkd.FormatDelegation(d.Archive())
+	if err != nil {
+		return fmt.Errorf("formatting delegation: %w", err)
+	}
+
+	if renewRecord {
+		if err := recordDelegation(d); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(out)
+	return nil
+}
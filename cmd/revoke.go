@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/client"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/receipt"
+	"github.com/storacha/go-ucanto/core/result"
+	"github.com/storacha/go-ucanto/did"
+	uhttp "github.com/storacha/go-ucanto/transport/http"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+)
+
+var (
+	// Revoke command flags
+	revokeIssuerPrivateKey string
+	revokeIssuerKeyURI     string
+	revokeIssuerDidWebKey  string
+	revokeAudienceDidKey   string
+	revokeServiceURL       string
+)
+
+// revokeCmd represents the revoke command
+var revokeCmd = &cobra.Command{
+	Use:     "revoke [DELEGATION_FILE]",
+	Aliases: []string{"r"},
+	Short:   "Generate a ucan/revoke invocation for a previously issued delegation",
+	Long: `Generates a ucan/revoke invocation for a delegation read from a file or stdin if no file is provided.
+   The revocation must be signed by the issuer of the delegation or by the issuer of a delegation in its
+   proof chain. The invocation is printed in the same encoding as generated delegations, and is optionally
+   submitted to a service endpoint.
+   Examples:
+     - Revoke from file: mkdelegation revoke -i key.pem -a did:web:up.storacha.network delegation.b64
+     - Revoke and submit: mkdelegation revoke -i key.pem -a did:web:up.storacha.network -u https://up.storacha.network delegation.b64`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         revokeDelegation,
+}
+
+func init() {
+	rootCmd.AddCommand(revokeCmd)
+
+	revokeCmd.Flags().StringVarP(&revokeIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of revocation issuer")
+	revokeCmd.Flags().StringVar(&revokeIssuerKeyURI, "issuer", "", "URI of the revocation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	revokeCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	revokeCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	revokeCmd.Flags().StringVarP(&revokeIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, when provided warps did:key: of revocation issuer")
+
+	revokeCmd.Flags().StringVarP(&revokeAudienceDidKey, "audience-did-key", "a", "", "DID of the service the revocation is addressed to")
+	Must(revokeCmd.MarkFlagRequired("audience-did-key"))
+
+	revokeCmd.Flags().StringVarP(&revokeServiceURL, "service-url", "u", "", "Optional URL of the service endpoint the revocation is submitted to")
+}
+
+func revokeDelegation(cmd *cobra.Command, args []string) error {
+	var path string
+	if len(args) >= 1 {
+		path = args[0]
+	}
+	target, err := loadDelegationArg(path)
+	if err != nil {
+		return err
+	}
+
+	issuer, err := loadSigner(revokeIssuerPrivateKey, revokeIssuerKeyURI, revokeIssuerDidWebKey)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(issuer)
+
+	audience, err := did.Parse(revokeAudienceDidKey)
+	if err != nil {
+		return fmt.Errorf("parsing audience did: %w", err)
+	}
+
+	inv, err := mkd.MakeRevocation(issuer, audience, target)
+	if err != nil {
+		return fmt.Errorf("making revocation: %w", err)
+	}
+
+	out, err := mkd.FormatDelegation(inv.Archive())
+	if err != nil {
+		return fmt.Errorf("formatting revocation: %w", err)
+	}
+	fmt.Println(out)
+
+	if revokeServiceURL == "" {
+		return nil
+	}
+	return submitRevocation(cmd, audience, inv)
+}
+
+// submitRevocation sends the revocation invocation to the configured service
+// endpoint and reports the outcome of its receipt.
+func submitRevocation(cmd *cobra.Command, audience did.DID, inv invocation.Invocation) error {
+	endpoint, err := url.Parse(revokeServiceURL)
+	if err != nil {
+		return fmt.Errorf("parsing service URL: %w", err)
+	}
+
+	conn, err := client.NewConnection(audience, uhttp.NewHTTPChannel(endpoint))
+	if err != nil {
+		return fmt.Errorf("creating service connection: %w", err)
+	}
+
+	res, err := client.Execute(cmd.Context(), []invocation.Invocation{inv}, conn)
+	if err != nil {
+		return fmt.Errorf("submitting revocation: %w", err)
+	}
+
+	rcptLink, ok := res.Get(inv.Link())
+	if !ok {
+		return fmt.Errorf("service response did not include a receipt for revocation %s", inv.Link())
+	}
+
+	rcpt, err := receipt.NewAnyReceiptReader().Read(rcptLink, res.Blocks())
+	if err != nil {
+		return fmt.Errorf("reading revocation receipt: %w", err)
+	}
+
+	_, x := result.Unwrap(rcpt.Out())
+	if x != nil {
+		return fmt.Errorf("service rejected revocation: %s", describeNode(x))
+	}
+
+	cmd.PrintErrf("Revocation %s accepted by %s\n", inv.Link(), audience)
+	return nil
+}
+
+// describeNode returns the message of a failure node when present, falling
+// back to its kind.
+func describeNode(n ipld.Node) string {
+	if msg, err := n.LookupByString("message"); err == nil {
+		if s, err := msg.AsString(); err == nil {
+			return s
+		}
+	}
+	return fmt.Sprintf("%v", n.Kind())
+}
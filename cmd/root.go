@@ -24,8 +24,16 @@ func Execute() {
 	}
 }
 
+var (
+	// Root command flags
+	ledgerPath string
+	policyPath string
+)
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
+	rootCmd.PersistentFlags().StringVar(&ledgerPath, "ledger", "", "Path to the issuance ledger (defaults to ledger.jsonl in the mkdelegation user config directory)")
+	rootCmd.PersistentFlags().StringVar(&policyPath, "policy", "", "Path to a YAML policy that issued delegations must comply with")
 }
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/core/delegation"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+	"github.com/storacha/go-mkdelegation/pkg/ledger"
+)
+
+var (
+	// Rotate command flags
+	rotateOldLedger        string
+	rotateFromDir          string
+	rotateOldIssuer        string
+	rotateIssuerPrivateKey string
+	rotateIssuerKeyURI     string
+	rotateIssuerDidWebKey  string
+	rotateOutDir           string
+	rotateRecord           bool
+	rotateProofFiles       []string
+)
+
+// rotatedDelegation maps a delegation to its reissued replacement
+type rotatedDelegation struct {
+	Old        string `json:"old"`
+	New        string `json:"new"`
+	Delegation string `json:"delegation"`
+}
+
+// rotateCmd represents the rotate command
+var rotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Reissue existing delegations under a new issuer key",
+	Long: `Reads existing delegations from an issuance ledger or a directory and re-signs equivalent delegations with a
+   new issuer key. Audiences, capabilities, facts, nonces and validity windows are preserved, and capabilities on
+   the resource of the old issuer are granted on the resource of the new issuer. Delegations issued by a did:web
+   keep their did:web issuer, wrapping the new key, and their proofs. The proofs of delegations whose issuer DID
+   changes are delegated to the old issuer and must be replaced by proofs delegated to the new issuer with --proof:
+   each delegation gets the supplied proofs that grant its capabilities.
+   The reissued delegations are printed as JSON together with the CID of the delegation they replace, or written
+   to --out-dir as <CID>.b64 files along with a mapping.json of old CID to new CID.
+   Examples:
+     - Rotate from the ledger: mkdelegation rotate --old-ledger ~/.config/mkdelegation/ledger.jsonl -i new-key.pem
+     - Rotate a directory: mkdelegation rotate --from-dir ./delegations -i new-key.pem --out-dir ./rotated
+     - Rotate with new proofs: mkdelegation rotate --from-dir ./delegations -i new-key.pem --proof new-root.b64`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         rotateDelegations,
+}
+
+func init() {
+	rootCmd.AddCommand(rotateCmd)
+
+	rotateCmd.Flags().StringVar(&rotateOldLedger, "old-ledger", "", "Path to the ledger holding the delegations to reissue")
+	rotateCmd.Flags().StringVar(&rotateFromDir, "from-dir", "", "Directory holding the delegations to reissue as .b64 files")
+	rotateCmd.MarkFlagsOneRequired("old-ledger", "from-dir")
+	rotateCmd.MarkFlagsMutuallyExclusive("old-ledger", "from-dir")
+
+	rotateCmd.Flags().StringVar(&rotateOldIssuer, "old-issuer", "", "Only reissue delegations issued by this DID")
+
+	rotateCmd.Flags().StringVarP(&rotateIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of the new delegation issuer")
+	rotateCmd.Flags().StringVar(&rotateIssuerKeyURI, "issuer", "", "URI of the new delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	rotateCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	rotateCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	rotateCmd.Flags().StringVarP(&rotateIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of the new issuer, defaults to the did:web: of each reissued delegation")
+	rotateCmd.Flags().StringVarP(&rotateOutDir, "out-dir", "o", "", "Directory to write the reissued delegations and the CID mapping to")
+	rotateCmd.Flags().StringArrayVar(&rotateProofFiles, "proof", nil, "Delegation file to the new issuer used as proof in place of the proofs of delegations whose issuer DID changes, when it grants their capabilities (repeatable)")
+	rotateCmd.Flags().BoolVar(&rotateRecord, "record", false, "record the reissued delegations in the local issuance ledger")
+}
+
+func rotateDelegations(cmd *cobra.Command, args []string) error {
+	key, err := openIssuerKey(rotateIssuerPrivateKey, rotateIssuerKeyURI)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(key)
+
+	olds, err := loadRotationSources()
+	if err != nil {
+		return err
+	}
+
+	var proofs []delegation.Delegation
+	for _, path := range rotateProofFiles {
+		ds, err := mkd.LoadBundle(path)
+		if err != nil {
+			return fmt.Errorf("loading proof %s: %w", path, err)
+		}
+		proofs = append(proofs, ds...)
+	}
+
+	rotated := []rotatedDelegation{}
+	var reissued []delegation.Delegation
+	for _, old := range olds {
+		if rotateOldIssuer != "" && old.Issuer().DID().String() != rotateOldIssuer {
+			continue
+		}
+
+		issuer, err := reissuingIssuer(key, rotateIssuerDidWebKey, old)
+		if err != nil {
+			return err
+		}
+		if issuer, err = policyIssuer(issuer); err != nil {
+			return err
+		}
+		// Proofs to the old issuer still support delegations reissued under the
+		// same DID, others get the supplied proofs that support them
+		var newProofs []delegation.Delegation
+		if len(old.Proofs()) > 0 && issuer.DID() != old.Issuer().DID() {
+			newProofs, err = mkd.SelectProofs(issuer.DID(), old, proofs)
+		}
+		var d delegation.Delegation
+		if err == nil {
+			d, err = mkd.Reissue(issuer, old, newProofs)
+		}
+		if errors.Is(err, mkd.ErrProofsRequired) {
+			return fmt.Errorf("%w (supply them with --proof)", err)
+		}
+		if err != nil {
+			return fmt.Errorf("reissuing %s: %w", old.Link(), err)
+		}
+		out, err := mkd.FormatDelegation(d.Archive())
+		if err != nil {
+			return fmt.Errorf("formatting delegation: %w", err)
+		}
+		rotated = append(rotated, rotatedDelegation{Old: old.Link().String(), New: d.Link().String(), Delegation: out})
+		reissued = append(reissued, d)
+	}
+
+	if rotateRecord {
+		for _, d := range reissued {
+			if err := recordDelegation(d); err != nil {
+				return err
+			}
+		}
+	}
+
+	if rotateOutDir == "" {
+		jsonOutput, err := json.MarshalIndent(rotated, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal rotated delegations to JSON: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(jsonOutput))
+		return nil
+	}
+
+	if err := os.MkdirAll(rotateOutDir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	mapping := map[string]string{}
+	for _, r := range rotated {
+		mapping[r.Old] = r.New
+		path := filepath.Join(rotateOutDir, r.New+".b64")
+		if err := os.WriteFile(path, []byte(r.Delegation+"\n"), 0o644); err != nil {
+			return fmt.Errorf("writing delegation %s: %w", r.New, err)
+		}
+	}
+	mappingJSON, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal CID mapping to JSON: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(rotateOutDir, "mapping.json"), append(mappingJSON, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing CID mapping: %w", err)
+	}
+	cmd.PrintErrf("Reissued %d delegation(s) to %s\n", len(rotated), rotateOutDir)
+	return nil
+}
+
+// loadRotationSources loads the delegations to reissue from the old ledger or
+// from the source directory
+func loadRotationSources() ([]delegation.Delegation, error) {
+	var olds []delegation.Delegation
+	if rotateOldLedger != "" {
+		l, err := ledger.Open(rotateOldLedger)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := l.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			d, err := mkd.DecodeDelegation(e.Delegation)
+			if err != nil {
+				return nil, fmt.Errorf("decoding ledger delegation %s: %w", e.CID, err)
+			}
+			olds = append(olds, d)
+		}
+		return olds, nil
+	}
+
+	files, err := findDelegationFiles(rotateFromDir, []string{".b64"})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", rotateFromDir, err)
+	}
+	for _, path := range files {
+		d, err := mkd.LoadDelegation(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load delegation from %s: %w", path, err)
+		}
+		olds = append(olds, d)
+	}
+	return olds, nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+	"github.com/storacha/go-mkdelegation/pkg/server"
+)
+
+var (
+	// Serve command flags
+	serveAddr             string
+	serveIssuerPrivateKey string
+	serveIssuerKeyURI     string
+	serveIssuerDidWebKey  string
+	serveTokensFile       string
+)
+
+// tokensConfig is the format of the tokens file
+type tokensConfig struct {
+	Tokens []server.Token `yaml:"tokens"`
+}
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an HTTP API for minting, parsing and verifying delegations",
+	Long: `Starts an HTTP server exposing:
+     - POST /delegations: mint a delegation from the configured issuer, e.g. {"audience": "did:key:...", "capabilities": ["blob/allocate"], "expiration": 1735689600}
+     - POST /parse: parse the delegation in the request body
+     - POST /verify: verify the signatures, time bounds and proof chain of the delegation in the request body
+   Requests must carry one of the bearer tokens in the tokens file, which lists the audiences and abilities the token
+   can mint delegations for ("*" for any); a token that lists none can only parse and verify. Minted delegations must comply with the policy passed with --policy, if any.
+   Requests are logged as JSON to stderr.
+   Example tokens file:
+     tokens:
+       - name: indexer
+         token: s3cret
+         audiences: [did:key:z6Mkh...]
+         abilities: [claim/cache]
+   Examples:
+     - Serve: mkdelegation serve -i service.pem --tokens tokens.yaml --addr :8080
+     - Serve with a key held by a signing agent: mkdelegation serve --issuer agent:///run/signer.sock --tokens tokens.yaml`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         serveAPI,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+
+	serveCmd.Flags().StringVarP(&serveIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of delegation issuer")
+	serveCmd.Flags().StringVar(&serveIssuerKeyURI, "issuer", "", "URI of the delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	serveCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	serveCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	serveCmd.Flags().StringVarP(&serveIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, when provided warps did:key: of delegation issuer")
+
+	serveCmd.Flags().StringVar(&serveTokensFile, "tokens", "", "Path to the YAML file of accepted bearer tokens")
+	Must(serveCmd.MarkFlagRequired("tokens"))
+}
+
+func serveAPI(cmd *cobra.Command, args []string) error {
+	issuer, err := loadSigner(serveIssuerPrivateKey, serveIssuerKeyURI, serveIssuerDidWebKey)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(issuer)
+
+	tokens, err := loadTokens(serveTokensFile)
+	if err != nil {
+		return err
+	}
+
+	var opts []server.Option
+	p, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	if p != nil {
+		opts = append(opts, server.WithPolicy(p))
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           server.New(issuer, tokens, logger, opts...),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		logger.Info("listening", slog.String("addr", serveAddr), slog.String("issuer", issuer.DID().String()))
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("serving: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("shutting down: %w", err)
+	}
+	return nil
+}
+
+// loadTokens reads the bearer tokens from a YAML tokens file
+func loadTokens(path string) ([]server.Token, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tokens file: %w", err)
+	}
+	var cfg tokensConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing tokens file %s: %w", path, err)
+	}
+	if len(cfg.Tokens) == 0 {
+		return nil, fmt.Errorf("no tokens in tokens file %s", path)
+	}
+	for i, t := range cfg.Tokens {
+		if t.Token == "" {
+			return nil, fmt.Errorf("token %d (%s) in tokens file %s is empty", i+1, t.Name, path)
+		}
+	}
+	return cfg.Tokens, nil
+}
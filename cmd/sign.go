@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
+)
+
+var (
+	// Sign command flags
+	signIssuerPrivateKey string
+	signIssuerKeyURI     string
+	signIssuerDidWebKey  string
+	signOut              string
+)
+
+// signCmd represents the sign command
+var signCmd = &cobra.Command{
+	Use:   "sign PAYLOAD",
+	Short: "Sign an unsigned delegation payload offline",
+	Long: `Signs an unsigned UCAN payload written by gen --unsigned-out with the issuer private key, typically on an
+   air-gapped machine, and writes the signature. The payload is summarized on stderr for review before it is signed.
+   Combine the payload and the signature into a delegation with assemble.
+   Examples:
+     - Sign a payload: mkdelegation sign payload.cbor -i root-key.pem -o sig.bin`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE:         signPayload,
+}
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+
+	signCmd.Flags().StringVarP(&signIssuerPrivateKey, "issuer-private-key", "i", "", "Path to Ed25519 private key (PEM, OpenSSH, JWK, seed or multibase) of delegation issuer")
+	signCmd.Flags().StringVar(&signIssuerKeyURI, "issuer", "", "URI of the delegation issuer key: a path, file://, env://, multibase:, agent://, agent+https:// or ssh-agent: (see README)")
+	signCmd.MarkFlagsOneRequired("issuer-private-key", "issuer")
+	signCmd.MarkFlagsMutuallyExclusive("issuer-private-key", "issuer")
+
+	signCmd.Flags().StringVarP(&signIssuerDidWebKey, "issuer-did-web", "w", "", "Optional did:web: of issuer, defaults to the did:web: issuer of the payload")
+	signCmd.Flags().StringVarP(&signOut, "out", "o", "", "File to write the signature to, stdout when not provided")
+}
+
+func signPayload(cmd *cobra.Command, args []string) error {
+	payload, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading payload: %w", err)
+	}
+	u, err := mkd.DecodePayload(payload)
+	if err != nil {
+		return err
+	}
+
+	didWeb := signIssuerDidWebKey
+	if didWeb == "" && strings.HasPrefix(u.Issuer().DID().String(), "did:web:") {
+		didWeb = u.Issuer().DID().String()
+	}
+	issuer, err := loadSigner(signIssuerPrivateKey, signIssuerKeyURI, didWeb)
+	if err != nil {
+		return err
+	}
+	defer keys.Close(issuer)
+
+	abilities := make([]string, 0, len(u.Capabilities()))
+	for _, c := range u.Capabilities() {
+		abilities = append(abilities, fmt.Sprintf("%s on %s", c.Can(), c.With()))
+	}
+	cmd.PrintErrf("Signing delegation from %s to %s of %s, %s\n", u.Issuer().DID(), u.Audience().DID(), strings.Join(abilities, ", "), formatExpiry(u.Expiration()))
+	checked, err := policyIssuer(issuer)
+	if err != nil {
+		return err
+	}
+
+	sig, err := mkd.SignPayload(checked, payload)
+	if err != nil {
+		return fmt.Errorf("signing payload: %w", err)
+	}
+
+	if signOut == "" {
+		_, err := cmd.OutOrStdout().Write(sig)
+		return err
+	}
+	if err := os.WriteFile(signOut, sig, 0o644); err != nil {
+		return fmt.Errorf("writing signature: %w", err)
+	}
+	cmd.PrintErrf("Wrote signature to %s\n", signOut)
+	return nil
+}
@@ -1,54 +1,131 @@
 package cmd
 
 import (
-	crypto_ed25519 "crypto/ed25519"
-	"crypto/x509"
-	"encoding/pem"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 
+	"github.com/spf13/cobra"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
 	"github.com/storacha/go-ucanto/principal"
-	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/keys"
 )
 
-func parsePrivateKeyPEM(f io.Reader) (principal.Signer, error) {
-	pemData, err := io.ReadAll(f)
+// openIssuerKey returns the did:key signer identified by a key URI (see
+// keys.Open), or by the private key at the provided path when no URI is
+// provided.
+func openIssuerKey(keyPath string, keyURI string) (ucan.Signer, error) {
+	if keyURI == "" {
+		if keyPath == "" {
+			return nil, fmt.Errorf("one of --issuer-private-key or --issuer is required")
+		}
+		key, err := parseIssuerKey(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("parsing issuer private key from file %s: %w", keyPath, err)
+		}
+		return key, nil
+	}
+
+	key, err := keys.Open(keyURI)
 	if err != nil {
-		return nil, fmt.Errorf("reading private key: %w", err)
+		return nil, fmt.Errorf("opening issuer key %s: %w", keyURI, err)
 	}
-	var privateKey *crypto_ed25519.PrivateKey
-	rest := pemData
+	return key, nil
+}
 
-	// Loop until no more blocks
-	for {
-		block, remaining := pem.Decode(rest)
-		if block == nil {
-			// No more PEM blocks
-			break
-		}
-		rest = remaining
-
-		// Look for "PRIVATE KEY"
-		if block.Type == "PRIVATE KEY" {
-			parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
-			}
-
-			// We expect a ed25519 private key, cast it
-			key, ok := parsedKey.(crypto_ed25519.PrivateKey)
-			if !ok {
-				return nil, fmt.Errorf("the parsed key is not an ED25519 private key")
-			}
-			privateKey = &key
-			break
+// wrapIssuer wraps the did:key signer with the did:web, when provided.
+func wrapIssuer(key ucan.Signer, didWeb string) (ucan.Signer, error) {
+	if didWeb == "" {
+		return key, nil
+	}
+	issuerDidWeb, err := parseDidWeb(didWeb)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := keys.Wrap(key, issuerDidWeb)
+	if err != nil {
+		return nil, fmt.Errorf("wrapping issuer with did web key (%s): %w", didWeb, err)
+	}
+	return issuer, nil
+}
+
+// loadSigner returns the issuer identified by a key URI (see keys.Open), or
+// by the private key at the provided path when no URI is provided, wrapped
+// with the did:web when one is provided.
+func loadSigner(keyPath string, keyURI string, didWeb string) (ucan.Signer, error) {
+	key, err := openIssuerKey(keyPath, keyURI)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := wrapIssuer(key, didWeb)
+	if err != nil {
+		keys.Close(key)
+		return nil, err
+	}
+	return issuer, nil
+}
+
+func parseDidWeb(didWeb string) (did.DID, error) {
+	if !strings.HasPrefix(didWeb, "did:web:") {
+		return did.DID{}, fmt.Errorf("issuer did:web: must start with 'did:web:' prefix")
+	}
+	id, err := did.Parse(didWeb)
+	if err != nil {
+		return did.DID{}, fmt.Errorf("parsing issuer did web key (%s): %w", didWeb, err)
+	}
+	return id, nil
+}
+
+// reissuingIssuer returns the issuer of a delegation reissued from an existing
+// one: the key, wrapped with the provided did:web or, when none is provided,
+// with the did:web issuer of the existing delegation
+func reissuingIssuer(key ucan.Signer, didWeb string, old delegation.Delegation) (ucan.Signer, error) {
+	if didWeb == "" && strings.HasPrefix(old.Issuer().DID().String(), "did:web:") {
+		didWeb = old.Issuer().DID().String()
+	}
+	return wrapIssuer(key, didWeb)
+}
+
+// parseIssuerKey attempts to read and parse the private key from the
+// provided path.
+func parseIssuerKey(path string) (principal.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file: %w", err)
+	}
+	return keys.Parse(data)
+}
+
+// loadDelegationArg decodes a delegation from the file at the provided path,
+// or from stdin when the path is empty or "-".
+func loadDelegationArg(path string) (delegation.Delegation, error) {
+	if path != "" && path != "-" {
+		d, err := mkd.LoadDelegation(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load delegation from %s: %w", path, err)
 		}
+		return d, nil
+	}
+
+	stdinData, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	if len(stdinData) == 0 {
+		return nil, fmt.Errorf("no input provided via stdin and no file specified")
 	}
 
-	if privateKey == nil {
-		return nil, fmt.Errorf("could not find a PRIVATE KEY block in the PEM file")
+	d, err := mkd.DecodeDelegation(string(stdinData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delegation from stdin: %w", err)
 	}
-	return ed25519.FromRaw(*privateKey)
+	return d, nil
 }
 
 // Must panics if err is not nil (for functions that only return error)
@@ -57,3 +134,66 @@ func Must(err error) {
 		panic(err)
 	}
 }
+
+// loadProofSources loads the delegations to resolve missing proofs from: the
+// files given, and the files of dir that hold a delegation or a bundle
+func loadProofSources(cmd *cobra.Command, dir string, files []string) ([]delegation.Delegation, error) {
+	var sources []delegation.Delegation
+	for _, path := range files {
+		ds, err := mkd.LoadBundle(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading proof %s: %w", path, err)
+		}
+		sources = append(sources, ds...)
+	}
+	if dir == "" {
+		return sources, nil
+	}
+	paths, err := findDelegationFiles(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading proofs directory: %w", err)
+	}
+	for _, path := range paths {
+		ds, err := mkd.LoadBundle(path)
+		if err != nil {
+			cmd.PrintErrf("Warning: skipping %s: %s\n", path, err)
+			continue
+		}
+		sources = append(sources, ds...)
+	}
+	return sources, nil
+}
+
+// encodeArchive encodes a delegation archive, or a bundle, in format: cid for
+// the multibase-base64-encoded CID form, base64 for the base64 encoding of
+// that form, or car for the raw CAR archive
+func encodeArchive(archive []byte, format string) ([]byte, error) {
+	switch format {
+	case "cid", "base64":
+		encoded, err := mkd.FormatDelegationBytes(archive)
+		if err != nil {
+			return nil, fmt.Errorf("formatting archive: %w", err)
+		}
+		if format == "base64" {
+			encoded = base64.StdEncoding.EncodeToString([]byte(encoded))
+		}
+		return []byte(encoded + "\n"), nil
+	case "car":
+		return archive, nil
+	default:
+		return nil, fmt.Errorf("unknown archive format: %s", format)
+	}
+}
+
+// writeOutput writes data to the file at the provided path, or to stdout when
+// the path is empty
+func writeOutput(cmd *cobra.Command, path string, data []byte) error {
+	if path == "" {
+		_, err := cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package delegation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Attenuate re-delegates a subset of the capabilities of the parent delegation
+// from its audience (the issuer) to a new audience. Each ability must be
+// covered by a capability of the parent, and is granted on the resource of
+// each parent capability that covers it, once per resource, with the caveats
+// of the first such capability carried over unchanged. The parent is
+// included as proof.
+//
+// When expiration is nil the expiration of the parent is inherited, and a
+// parent that has already expired is refused. An expiration in the past or
+// beyond that of the parent is refused, as is an ability that the parent does
+// not grant.
+func Attenuate(issuer ucan.Signer, audience ucan.Principal, parent delegation.Delegation, abilities []string, expiration *int) (delegation.Delegation, error) {
+	if issuer.DID() != parent.Audience().DID() {
+		return nil, fmt.Errorf("issuer %s is not the audience of the parent delegation (%s)", issuer.DID(), parent.Audience().DID())
+	}
+	if len(abilities) == 0 {
+		return nil, fmt.Errorf("no abilities to delegate")
+	}
+
+	var uc []ucan.Capability[nodeCaveats]
+	granted := map[[2]string]bool{}
+	for _, ability := range abilities {
+		var covered, allAbilities bool
+		for _, pc := range parent.Capabilities() {
+			allAbilities = allAbilities || pc.Can() == "*/*"
+			if !AbilityCovers(pc.Can(), ability) {
+				continue
+			}
+			covered = true
+			key := [2]string{pc.With(), ability}
+			if granted[key] {
+				continue
+			}
+			granted[key] = true
+			nb, _ := pc.Nb().(datamodel.Node)
+			uc = append(uc, ucan.NewCapability(ability, pc.With(), nodeCaveats{nb}))
+		}
+		switch {
+		case !covered && allAbilities:
+			return nil, fmt.Errorf("ability %s is not granted by the parent delegation: the parent grants */*, which covers no ability in ucanto, where * grants all abilities", ability)
+		case !covered:
+			return nil, fmt.Errorf("ability %s is not granted by the parent delegation", ability)
+		}
+	}
+
+	opts := []delegation.Option{
+		delegation.WithProof(delegation.FromDelegation(parent)),
+		delegation.WithNotBefore(parent.NotBefore()),
+	}
+	parentExp := parent.Expiration()
+	switch {
+	case expiration == nil && parentExp == nil:
+		opts = append(opts, delegation.WithNoExpiration())
+	case expiration == nil && int64(*parentExp) <= time.Now().Unix():
+		return nil, fmt.Errorf("the parent delegation expired at %d", *parentExp)
+	case expiration == nil:
+		opts = append(opts, delegation.WithExpiration(*parentExp))
+	case int64(*expiration) <= time.Now().Unix():
+		return nil, fmt.Errorf("expiration %d is in the past", *expiration)
+	case parentExp != nil && *expiration > *parentExp:
+		return nil, fmt.Errorf("expiration %d is beyond the expiration of the parent delegation (%d)", *expiration, *parentExp)
+	default:
+		opts = append(opts, delegation.WithExpiration(*expiration))
+	}
+
+	return delegate(issuer, audience, uc, opts...)
+}
+
+// AbilityCovers reports whether a granted ability, e.g. that of a parent capability,
+// covers the requested ability, accounting for "*" and "namespace/*" wildcards.
+func AbilityCovers(granted, requested string) bool {
+	if granted == "*" || granted == requested {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(granted, "/*"); ok {
+		return strings.HasPrefix(requested, prefix+"/")
+	}
+	return false
+}
+
+// nodeCaveats carries caveats that are already in IPLD form.
+type nodeCaveats struct {
+	node datamodel.Node
+}
+
+func (c nodeCaveats) ToIPLD() (datamodel.Node, error) {
+	if c.node == nil {
+		return ucan.NoCaveats{}.ToIPLD()
+	}
+	return c.node, nil
+}
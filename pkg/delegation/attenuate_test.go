@@ -0,0 +1,130 @@
+package delegation
+
+import (
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttenuate(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	downstream, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	parentExp := int(time.Now().Add(48 * time.Hour).Unix())
+	parent, err := MakeDelegation(service, operator, []string{"blob/*", capblob.AcceptAbility}, delegation.WithExpiration(parentExp))
+	require.NoError(t, err)
+
+	t.Run("SubsetOfAbilities", func(t *testing.T) {
+		child, err := Attenuate(operator, downstream, parent, []string{capblob.AllocateAbility}, nil)
+		require.NoError(t, err)
+
+		require.Len(t, child.Capabilities(), 1)
+		assert.Equal(t, capblob.AllocateAbility, child.Capabilities()[0].Can())
+		assert.Equal(t, service.DID().String(), child.Capabilities()[0].With())
+		assert.Equal(t, operator.DID(), child.Issuer().DID())
+		assert.Equal(t, downstream.DID(), child.Audience().DID())
+		require.Len(t, child.Proofs(), 1)
+		assert.Equal(t, parent.Link(), child.Proofs()[0])
+
+		require.NotNil(t, child.Expiration())
+		assert.Equal(t, parentExp, *child.Expiration())
+	})
+
+	t.Run("ShorterExpiration", func(t *testing.T) {
+		exp := int(time.Now().Add(24 * time.Hour).Unix())
+		child, err := Attenuate(operator, downstream, parent, []string{capblob.AcceptAbility}, &exp)
+		require.NoError(t, err)
+
+		require.NotNil(t, child.Expiration())
+		assert.Equal(t, exp, *child.Expiration())
+	})
+
+	t.Run("EscalatedExpiration", func(t *testing.T) {
+		exp := parentExp + 1
+		_, err := Attenuate(operator, downstream, parent, []string{capblob.AcceptAbility}, &exp)
+		require.Error(t, err)
+	})
+
+	t.Run("PastExpiration", func(t *testing.T) {
+		exp := int(time.Now().Add(-time.Minute).Unix())
+		_, err := Attenuate(operator, downstream, parent, []string{capblob.AcceptAbility}, &exp)
+		require.ErrorContains(t, err, "in the past")
+	})
+
+	t.Run("OverlappingParentCapabilities", func(t *testing.T) {
+		// blob/accept is covered by both blob/* and blob/accept of the parent
+		child, err := Attenuate(operator, downstream, parent, []string{capblob.AcceptAbility}, nil)
+		require.NoError(t, err)
+		require.Len(t, child.Capabilities(), 1)
+		assert.Equal(t, capblob.AcceptAbility, child.Capabilities()[0].Can())
+	})
+
+	t.Run("SeveralResources", func(t *testing.T) {
+		space, err := ed25519.Generate()
+		require.NoError(t, err)
+		parent, err := delegation.Delegate(service, operator, []ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability("blob/*", service.DID().String(), ucan.NoCaveats{}),
+			ucan.NewCapability(capblob.AllocateAbility, space.DID().String(), ucan.NoCaveats{}),
+			ucan.NewCapability(capblob.AllocateAbility, service.DID().String(), ucan.NoCaveats{}),
+		})
+		require.NoError(t, err)
+
+		child, err := Attenuate(operator, downstream, parent, []string{capblob.AllocateAbility}, nil)
+		require.NoError(t, err)
+		var with []string
+		for _, c := range child.Capabilities() {
+			assert.Equal(t, capblob.AllocateAbility, c.Can())
+			with = append(with, c.With())
+		}
+		assert.Equal(t, []string{service.DID().String(), space.DID().String()}, with)
+	})
+
+	t.Run("ExpiredParent", func(t *testing.T) {
+		expired, err := MakeDelegation(service, operator, []string{"blob/*"}, delegation.WithExpiration(int(time.Now().Add(-time.Minute).Unix())))
+		require.NoError(t, err)
+		_, err = Attenuate(operator, downstream, expired, []string{capblob.AllocateAbility}, nil)
+		require.ErrorContains(t, err, "parent delegation expired")
+	})
+
+	t.Run("AllAbilitiesPattern", func(t *testing.T) {
+		all, err := MakeDelegation(service, operator, []string{"*/*"})
+		require.NoError(t, err)
+		_, err = Attenuate(operator, downstream, all, []string{capblob.AllocateAbility}, nil)
+		require.ErrorContains(t, err, "*/*, which covers no ability in ucanto")
+	})
+
+	t.Run("EscalatedAbility", func(t *testing.T) {
+		_, err := Attenuate(operator, downstream, parent, []string{"space/blob/add"}, nil)
+		require.Error(t, err)
+
+		_, err = Attenuate(operator, downstream, parent, []string{"*"}, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("IssuerNotAudience", func(t *testing.T) {
+		_, err := Attenuate(downstream, operator, parent, []string{capblob.AcceptAbility}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestAbilityCovers(t *testing.T) {
+	assert.True(t, AbilityCovers("*", "blob/allocate"))
+	assert.True(t, AbilityCovers("blob/allocate", "blob/allocate"))
+	assert.True(t, AbilityCovers("blob/*", "blob/allocate"))
+	assert.True(t, AbilityCovers("blob/*", "blob/*"))
+	assert.False(t, AbilityCovers("blob/*", "space/blob/add"))
+	assert.False(t, AbilityCovers("blob/allocate", "blob/*"))
+	assert.False(t, AbilityCovers("blob/allocate", "blob/accept"))
+}
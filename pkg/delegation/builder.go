@@ -0,0 +1,208 @@
+package delegation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Builder builds a delegation step by step:
+//
+//	d, err := delegation.New(issuer).
+//		To(audience).
+//		Grant("blob/allocate", issuer.DID().String(), nil).
+//		WithProof(parent).
+//		ExpiresIn(24 * time.Hour).
+//		Build()
+//
+// Invalid arguments do not stop the chain, they are collected and returned
+// together by Build. Delegations never expire unless an expiration is set.
+type Builder struct {
+	issuer   ucan.Signer
+	audience ucan.Principal
+	caps     []ucan.Capability[nodeCaveats]
+	proofs   []delegation.Delegation
+	facts    []ucan.FactBuilder
+	nonce    string
+
+	notBefore  time.Time
+	expiresAt  time.Time
+	expiresIn  time.Duration
+	expiration bool
+
+	errs error
+}
+
+// New returns a builder of a delegation from the issuer
+func New(issuer ucan.Signer) *Builder {
+	b := &Builder{issuer: issuer}
+	if issuer == nil {
+		b.fail("issuer is required")
+	}
+	return b
+}
+
+func (b *Builder) fail(format string, a ...any) {
+	b.errs = multierror.Append(b.errs, fmt.Errorf(format, a...))
+}
+
+// To sets the audience of the delegation
+func (b *Builder) To(audience ucan.Principal) *Builder {
+	if audience == nil {
+		b.fail("audience is required")
+		return b
+	}
+	b.audience = audience
+	return b
+}
+
+// Grant adds a capability for the ability on the resource, restricted by the
+// caveats when nb is not nil
+func (b *Builder) Grant(can string, with string, nb ucan.CaveatBuilder) *Builder {
+	if can != "*" && !strings.Contains(can, "/") {
+		b.fail("ability %q must be \"*\" or of the form namespace/name", can)
+	}
+	if !strings.Contains(with, ":") {
+		b.fail("resource %q of %s must be a URI", with, can)
+	}
+
+	var node datamodel.Node
+	if nb != nil {
+		var err error
+		node, err = nb.ToIPLD()
+		if err != nil {
+			b.fail("encoding caveats of %s: %w", can, err)
+		}
+	}
+	b.caps = append(b.caps, ucan.NewCapability(can, with, nodeCaveats{node}))
+	return b
+}
+
+// WithProof includes delegations as proofs of the delegation. Each must be
+// delegated to the issuer.
+func (b *Builder) WithProof(proofs ...delegation.Delegation) *Builder {
+	for _, p := range proofs {
+		if p == nil {
+			b.fail("proof is nil")
+			continue
+		}
+		b.proofs = append(b.proofs, p)
+	}
+	return b
+}
+
+// WithFact adds a fact to the delegation
+func (b *Builder) WithFact(fact ucan.FactBuilder) *Builder {
+	b.facts = append(b.facts, fact)
+	return b
+}
+
+// WithNonce sets the nonce of the delegation
+func (b *Builder) WithNonce(nonce string) *Builder {
+	b.nonce = nonce
+	return b
+}
+
+// NotBefore sets the time before which the delegation is not valid
+func (b *Builder) NotBefore(t time.Time) *Builder {
+	b.notBefore = t
+	return b
+}
+
+// ExpiresIn sets the expiration of the delegation relative to the time it is
+// built
+func (b *Builder) ExpiresIn(d time.Duration) *Builder {
+	if d <= 0 {
+		b.fail("expires in %s must be positive", d)
+	}
+	b.setExpiration()
+	b.expiresIn = d
+	return b
+}
+
+// ExpiresAt sets the expiration of the delegation
+func (b *Builder) ExpiresAt(t time.Time) *Builder {
+	if t.IsZero() {
+		b.fail("expires at is the zero time")
+	}
+	b.setExpiration()
+	b.expiresAt = t
+	return b
+}
+
+func (b *Builder) setExpiration() {
+	if b.expiration {
+		b.fail("expiration is set more than once")
+	}
+	b.expiration = true
+}
+
+// Build validates the delegation and signs it with the issuer. All
+// validation errors are returned together as a *multierror.Error.
+func (b *Builder) Build() (delegation.Delegation, error) {
+	now := time.Now()
+	errs := b.errs
+	fail := func(format string, a ...any) {
+		errs = multierror.Append(errs, fmt.Errorf(format, a...))
+	}
+
+	if b.audience == nil && b.issuer != nil {
+		fail("audience is required, set it with To")
+	}
+	if len(b.caps) == 0 {
+		fail("no capabilities granted, add them with Grant")
+	}
+	if b.issuer != nil {
+		for _, p := range b.proofs {
+			if p.Audience().DID() != b.issuer.DID() {
+				fail("proof %s is delegated to %s, not to the issuer %s", p.Link(), p.Audience().DID(), b.issuer.DID())
+			}
+		}
+	}
+
+	var opts []delegation.Option
+	var expiration time.Time
+	switch {
+	case !b.expiration:
+		opts = append(opts, delegation.WithNoExpiration())
+	case b.expiresIn > 0:
+		expiration = now.Add(b.expiresIn)
+	case !b.expiresAt.IsZero():
+		expiration = b.expiresAt
+		if !expiration.After(now) {
+			fail("expiration %s is in the past", expiration.UTC().Format(time.RFC3339))
+		}
+	}
+	if !expiration.IsZero() {
+		opts = append(opts, delegation.WithExpiration(int(expiration.Unix())))
+	}
+	if !b.notBefore.IsZero() {
+		if !expiration.IsZero() && !b.notBefore.Before(expiration) {
+			fail("not before %s is not before the expiration %s", b.notBefore.UTC().Format(time.RFC3339), expiration.UTC().Format(time.RFC3339))
+		}
+		opts = append(opts, delegation.WithNotBefore(int(b.notBefore.Unix())))
+	}
+	if b.nonce != "" {
+		opts = append(opts, delegation.WithNonce(b.nonce))
+	}
+	if len(b.facts) > 0 {
+		opts = append(opts, delegation.WithFacts(b.facts))
+	}
+	if len(b.proofs) > 0 {
+		proofs := make([]delegation.Proof, 0, len(b.proofs))
+		for _, p := range b.proofs {
+			proofs = append(proofs, delegation.FromDelegation(p))
+		}
+		opts = append(opts, delegation.WithProof(proofs...))
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+	return delegate(b.issuer, b.audience, b.caps, opts...)
+}
@@ -0,0 +1,122 @@
+package delegation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spaceCaveats struct {
+	space string
+}
+
+func (c spaceCaveats) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, 1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "space", qp.String(c.space))
+	})
+}
+
+func TestBuilder(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	parent, err := New(service).
+		To(operator).
+		Grant("blob/*", service.DID().String(), nil).
+		Build()
+	require.NoError(t, err)
+
+	t.Run("Build", func(t *testing.T) {
+		nb := spaceCaveats{audience.DID().String()}
+		d, err := New(operator).
+			To(audience).
+			Grant(capblob.AllocateAbility, service.DID().String(), nb).
+			Grant(capblob.AcceptAbility, service.DID().String(), nil).
+			WithProof(parent).
+			WithNonce("nonce").
+			WithFact(nodeFact{"purpose": basicnode.NewString("test")}).
+			NotBefore(time.Unix(1000, 0)).
+			ExpiresIn(time.Hour).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, operator.DID(), d.Issuer().DID())
+		assert.Equal(t, audience.DID(), d.Audience().DID())
+		require.Len(t, d.Capabilities(), 2)
+		assert.Equal(t, capblob.AllocateAbility, d.Capabilities()[0].Can())
+		assert.Equal(t, service.DID().String(), d.Capabilities()[0].With())
+		assert.Equal(t, capblob.AcceptAbility, d.Capabilities()[1].Can())
+		require.Len(t, d.Proofs(), 1)
+		assert.Equal(t, parent.Link(), d.Proofs()[0])
+		assert.Equal(t, "nonce", d.Nonce())
+		assert.Equal(t, 1000, d.NotBefore())
+		require.NotNil(t, d.Expiration())
+		assert.InDelta(t, time.Now().Add(time.Hour).Unix(), *d.Expiration(), 5)
+
+		info := Inspect(d)
+		assert.JSONEq(t, `{"space":"`+audience.DID().String()+`"}`, string(info.Capabilities[0].Nb))
+		assert.JSONEq(t, `{"purpose":"test"}`, string(info.Facts[0]))
+		assert.Empty(t, ProofChainIssues(info))
+	})
+
+	t.Run("NoExpirationByDefault", func(t *testing.T) {
+		assert.Nil(t, parent.Expiration())
+	})
+
+	t.Run("MatchesMakeDelegation", func(t *testing.T) {
+		exp := time.Now().Add(time.Hour)
+		built, err := New(service).
+			To(operator).
+			Grant(capblob.AllocateAbility, service.DID().String(), nil).
+			ExpiresAt(exp).
+			Build()
+		require.NoError(t, err)
+
+		made, err := MakeDelegation(service, operator, []string{capblob.AllocateAbility}, delegation.WithExpiration(int(exp.Unix())))
+		require.NoError(t, err)
+		assert.Equal(t, made.Link(), built.Link())
+	})
+
+	t.Run("CollectsErrors", func(t *testing.T) {
+		_, err := New(audience).
+			Grant("allocate", "service", nil).
+			WithProof(parent, nil).
+			ExpiresAt(time.Now().Add(-time.Hour)).
+			ExpiresIn(time.Hour).
+			Build()
+		require.Error(t, err)
+
+		var merr *multierror.Error
+		require.ErrorAs(t, err, &merr)
+		assert.Len(t, merr.Errors, 6)
+		assert.Contains(t, err.Error(), `ability "allocate" must be "*" or of the form namespace/name`)
+		assert.Contains(t, err.Error(), `resource "service" of allocate must be a URI`)
+		assert.Contains(t, err.Error(), "proof is nil")
+		assert.Contains(t, err.Error(), "expiration is set more than once")
+		assert.Contains(t, err.Error(), "audience is required")
+		assert.Contains(t, err.Error(), "not to the issuer")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := New(operator).To(audience).Build()
+		require.ErrorContains(t, err, "no capabilities granted")
+
+		_, err = New(operator).To(audience).Grant("*", "ucan:*", nil).ExpiresAt(time.Now().Add(-time.Minute)).Build()
+		require.ErrorContains(t, err, "is in the past")
+	})
+}
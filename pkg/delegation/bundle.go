@@ -0,0 +1,157 @@
+package delegation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/storacha/go-ucanto/core/car"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	adm "github.com/storacha/go-ucanto/core/delegation/datamodel"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/ipld/block"
+	"github.com/storacha/go-ucanto/core/ipld/codec/cbor"
+	"github.com/storacha/go-ucanto/core/ipld/hash/sha256"
+)
+
+// Bundle returns a CAR archive holding several delegations, with one root
+// per delegation in the order given. Each root is the archive descriptor
+// block that the archive of a single delegation has as its only root, and
+// blocks shared by the delegations are included once.
+func Bundle(delegations ...delegation.Delegation) ([]byte, error) {
+	if len(delegations) == 0 {
+		return nil, errors.New("no delegations to bundle")
+	}
+
+	var roots []ipld.Link
+	var blocks []ipld.Block
+	included := map[string]bool{}
+	add := func(b ipld.Block) {
+		if key := b.Link().String(); !included[key] {
+			included[key] = true
+			blocks = append(blocks, b)
+		}
+	}
+	for _, d := range delegations {
+		variant, err := block.Encode(&adm.ArchiveModel{Ucan0_9_1: d.Link()}, adm.Type(), cbor.Codec, sha256.Hasher)
+		if err != nil {
+			return nil, fmt.Errorf("encoding archive descriptor of %s: %w", d.Link(), err)
+		}
+		if included[variant.Link().String()] {
+			return nil, fmt.Errorf("delegation %s is bundled more than once", d.Link())
+		}
+		for b, err := range d.Export() {
+			if err != nil {
+				return nil, fmt.Errorf("exporting delegation %s: %w", d.Link(), err)
+			}
+			add(b)
+		}
+		add(variant)
+		roots = append(roots, variant.Link())
+	}
+
+	return io.ReadAll(car.Encode(roots, func(yield func(ipld.Block, error) bool) {
+		for _, b := range blocks {
+			if !yield(b, nil) {
+				return
+			}
+		}
+	}))
+}
+
+// ExtractBundle returns the delegations of a CAR archive holding one or more
+// delegations, such as the archive of a single delegation or one created by
+// Bundle. The blocks of each delegation are those reachable from it in the
+// archive, so a proof referenced by CID is resolved when another delegation
+// of the bundle includes it. Failures match ErrInvalidArchive.
+func ExtractBundle(archive []byte) ([]delegation.Delegation, error) {
+	ds, err := extractBundle(archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	return ds, nil
+}
+
+func extractBundle(archive []byte) ([]delegation.Delegation, error) {
+	roots, blks, err := car.Decode(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("decoding CAR: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, errors.New("missing root CID in delegation archive")
+	}
+	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(blks))
+	if err != nil {
+		return nil, fmt.Errorf("creating block reader: %w", err)
+	}
+
+	ds := make([]delegation.Delegation, 0, len(roots))
+	for _, root := range roots {
+		rt, ok, err := br.Get(root)
+		if err != nil {
+			return nil, fmt.Errorf("getting root block %s: %w", root, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing root block: %s", root)
+		}
+		model := adm.ArchiveModel{}
+		if err := block.Decode(rt, &model, adm.Type(), cbor.Codec, sha256.Hasher); err != nil {
+			return nil, fmt.Errorf("decoding root block %s: %w", root, err)
+		}
+		d, err := delegation.NewDelegationView(model.Ucan0_9_1, br)
+		if err != nil {
+			return nil, fmt.Errorf("decoding delegation %s: %w", model.Ucan0_9_1, err)
+		}
+		// Keep the blocks the delegation depends on, rather than the blocks of
+		// every delegation of the bundle
+		var blocks []ipld.Block
+		for b, err := range d.Export() {
+			if err != nil {
+				return nil, fmt.Errorf("exporting delegation %s: %w", d.Link(), err)
+			}
+			blocks = append(blocks, b)
+		}
+		bs, err := blockstore.NewBlockReader(blockstore.WithBlocks(blocks))
+		if err != nil {
+			return nil, fmt.Errorf("reading blocks of delegation %s: %w", d.Link(), err)
+		}
+		if d, err = delegation.NewDelegationView(d.Link(), bs); err != nil {
+			return nil, fmt.Errorf("decoding delegation %s: %w", model.Ucan0_9_1, err)
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+// DecodeBundle decodes the delegations of a bundle, or the single delegation
+// of an archive, from the encodings accepted by DecodeDelegation
+func DecodeBundle(content string) ([]delegation.Delegation, error) {
+	return decodeContent(content, extractBundle)
+}
+
+// DecodeBundleBytes decodes the delegations of a bundle like DecodeBundle, and
+// also accepts the raw bytes of the CAR archive
+func DecodeBundleBytes(data []byte) ([]delegation.Delegation, error) {
+	ds, err := DecodeBundle(string(data))
+	if err == nil || !errors.Is(err, ErrInvalidEncoding) {
+		return ds, err
+	}
+	if ds, carErr := extractBundle(data); carErr == nil {
+		return ds, nil
+	}
+	return nil, err
+}
+
+// LoadBundle reads a bundle or a single delegation from a file, in any of the
+// encodings accepted by DecodeBundleBytes, and decodes its delegations
+func LoadBundle(filePath string) ([]delegation.Delegation, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delegation file: %w", err)
+	}
+
+	return DecodeBundleBytes(data)
+}
@@ -0,0 +1,135 @@
+package delegation
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundle(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	root, err := New(service).To(operator).Grant("blob/*", service.DID().String(), nil).Build()
+	require.NoError(t, err)
+
+	var ds []delegation.Delegation
+	for range 2 {
+		node, err := ed25519.Generate()
+		require.NoError(t, err)
+		d, err := New(operator).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(root).Build()
+		require.NoError(t, err)
+		ds = append(ds, d)
+	}
+	links := func(ds []delegation.Delegation) []ucan.Link {
+		var ls []ucan.Link
+		for _, d := range ds {
+			ls = append(ls, d.Link())
+		}
+		return ls
+	}
+
+	archive, err := Bundle(ds...)
+	require.NoError(t, err)
+
+	t.Run("Extract", func(t *testing.T) {
+		extracted, err := ExtractBundle(archive)
+		require.NoError(t, err)
+		assert.Equal(t, links(ds), links(extracted))
+		// the shared proof is included once and resolves for each delegation
+		for _, d := range extracted {
+			info := Inspect(d)
+			require.Len(t, info.ProofDelegations, 1)
+			assert.Equal(t, root.Link().String(), info.ProofDelegations[0].CID)
+		}
+
+		single, err := io.ReadAll(ds[0].Archive())
+		require.NoError(t, err)
+		assert.Less(t, len(archive), 2*len(single))
+	})
+
+	t.Run("BlocksOfEachDelegation", func(t *testing.T) {
+		node, err := ed25519.Generate()
+		require.NoError(t, err)
+		// a references the root proof by CID only, b includes it
+		a, err := MakeDelegation(operator, node, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(root.Link())))
+		require.NoError(t, err)
+		b, err := MakeDelegation(operator, node, []string{capblob.AcceptAbility}, delegation.WithProof(delegation.FromDelegation(root)))
+		require.NoError(t, err)
+
+		archive, err := Bundle(a, b)
+		require.NoError(t, err)
+		extracted, err := ExtractBundle(archive)
+		require.NoError(t, err)
+		require.Len(t, extracted, 2)
+
+		blockLinks := func(d delegation.Delegation) []string {
+			var ls []string
+			for blk, err := range d.Blocks() {
+				require.NoError(t, err)
+				ls = append(ls, blk.Link().String())
+			}
+			return ls
+		}
+		aBlocks, bBlocks := blockLinks(extracted[0]), blockLinks(extracted[1])
+		assert.NotContains(t, aBlocks, b.Link().String())
+		assert.NotContains(t, bBlocks, a.Link().String())
+		// the proof b includes is reachable from a in the bundle
+		assert.Contains(t, aBlocks, root.Link().String())
+		assert.Contains(t, bBlocks, root.Link().String())
+	})
+
+	t.Run("Encodings", func(t *testing.T) {
+		encoded, err := FormatDelegationBytes(archive)
+		require.NoError(t, err)
+		decoded, err := DecodeBundle(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, links(ds), links(decoded))
+
+		dir := t.TempDir()
+		for name, data := range map[string][]byte{"bundle.b64": []byte(encoded + "\n"), "bundle.car": archive} {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(path, data, 0644))
+			loaded, err := LoadBundle(path)
+			require.NoError(t, err, name)
+			assert.Equal(t, links(ds), links(loaded), name)
+		}
+
+		// a bundle is not a single delegation
+		_, err = DecodeDelegation(encoded)
+		assert.ErrorIs(t, err, ErrInvalidArchive)
+	})
+
+	t.Run("SingleDelegation", func(t *testing.T) {
+		encoded, err := FormatDelegation(ds[0].Archive())
+		require.NoError(t, err)
+		decoded, err := DecodeBundle(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, links(ds[:1]), links(decoded))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Bundle()
+		assert.Error(t, err)
+
+		_, err = Bundle(ds[0], ds[1], ds[0])
+		assert.ErrorContains(t, err, "more than once")
+
+		_, err = ExtractBundle([]byte("not a CAR"))
+		assert.ErrorIs(t, err, ErrInvalidArchive)
+
+		_, err = DecodeBundleBytes([]byte("not a delegation!"))
+		assert.ErrorIs(t, err, ErrInvalidEncoding)
+	})
+}
@@ -0,0 +1,47 @@
+package delegation
+
+import (
+	"github.com/hashicorp/go-multierror"
+	"github.com/storacha/go-libstoracha/capabilities/assert"
+	"github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/storacha/go-libstoracha/capabilities/http"
+	"github.com/storacha/go-libstoracha/capabilities/pdp"
+	spaceblob "github.com/storacha/go-libstoracha/capabilities/space/blob"
+	ucancap "github.com/storacha/go-libstoracha/capabilities/ucan"
+)
+
+// KnownCapabilities is the set of known storacha service capabilities for a delegation
+// TODO: define a set in go-libstoracha that can be updated independently of this
+var KnownCapabilities = map[string]bool{
+	assert.EqualsAbility:       true,
+	assert.RelationAbility:     true,
+	assert.PartitionAbility:    true,
+	assert.IndexAbility:        true,
+	assert.InclusionAbility:    true,
+	assert.LocationAbility:     true,
+	blob.AcceptAbility:         true,
+	blob.AllocateAbility:       true,
+	claim.CacheAbility:         true,
+	http.PutAbility:            true,
+	pdp.AcceptAbility:          true,
+	pdp.InfoAbility:            true,
+	spaceblob.AddAbility:       true,
+	spaceblob.GetAbility:       true,
+	spaceblob.ListAbility:      true,
+	spaceblob.RemoveAbility:    true,
+	spaceblob.ReplicateAbility: true,
+	ucancap.ConcludeAbility:    true,
+}
+
+// ValidateCapabilities returns an UnknownCapabilityError for each ability that
+// is not in KnownCapabilities, or nil when all are known
+func ValidateCapabilities(abilities []string) error {
+	var errs error
+	for _, ability := range abilities {
+		if !KnownCapabilities[ability] {
+			errs = multierror.Append(errs, &UnknownCapabilityError{Ability: ability})
+		}
+	}
+	return errs
+}
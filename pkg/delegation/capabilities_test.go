@@ -0,0 +1,22 @@
+package delegation
+
+import (
+	"errors"
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCapabilities(t *testing.T) {
+	assert.NoError(t, ValidateCapabilities([]string{capblob.AllocateAbility, capclaim.CacheAbility}))
+
+	err := ValidateCapabilities([]string{capblob.AllocateAbility, "custom/thing", "*"})
+	require.ErrorIs(t, err, ErrUnknownCapability)
+	var unknown *UnknownCapabilityError
+	require.True(t, errors.As(err, &unknown))
+	assert.Equal(t, "custom/thing", unknown.Ability)
+	assert.ErrorContains(t, err, "unknown capability: *")
+}
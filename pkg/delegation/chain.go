@@ -0,0 +1,75 @@
+package delegation
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ProofChainIssues returns the problems found in the proof chain of a
+// delegation: proofs whose audience is not the issuer of the delegation they
+// support, proofs that are referenced by CID but not included, and proofs
+// that could not be read. Proofs beyond the depth the chain was inspected to
+// are not checked.
+func ProofChainIssues(info *DelegationInfo) []string {
+	byCID := map[string]*DelegationInfo{}
+	Walk(info, func(d *DelegationInfo) {
+		byCID[d.CID] = d
+	})
+	return proofChainIssues(info, byCID)
+}
+
+func proofChainIssues(info *DelegationInfo, byCID map[string]*DelegationInfo) []string {
+	var issues []string
+
+	included := map[string]bool{}
+	proofs := slices.Clone(info.ProofDelegations)
+	// Proofs shared with another delegation of the chain are inspected there
+	for _, cid := range info.DuplicateProofs {
+		if pd, ok := byCID[cid]; ok {
+			proofs = append(proofs, pd)
+		}
+	}
+	for _, pd := range proofs {
+		included[pd.CID] = true
+		if pd.Audience != info.Issuer {
+			issues = append(issues, fmt.Sprintf("proof %s is delegated to %s, not to the issuer %s of %s", pd.CID, pd.Audience, info.Issuer, info.CID))
+		}
+	}
+	for _, cid := range proofCIDs(info) {
+		// Proofs that could not be read are reported with their error below
+		if !included[cid] && !slices.Contains(info.ErroredProofs, cid) && !info.Truncated {
+			issues = append(issues, fmt.Sprintf("proof %s of %s is not included", cid, info.CID))
+		}
+	}
+	for _, e := range info.ProofErrors {
+		issues = append(issues, fmt.Sprintf("%s of %s", e, info.CID))
+	}
+
+	for _, pd := range info.ProofDelegations {
+		issues = append(issues, proofChainIssues(pd, byCID)...)
+	}
+	return issues
+}
+
+// UnresolvedProofs returns the CIDs of the proofs referenced in the chain of
+// a delegation that were neither included in its archive nor resolved from
+// the proof sources it was inspected with
+func UnresolvedProofs(info *DelegationInfo) []string {
+	var cids []string
+	Walk(info, func(d *DelegationInfo) {
+		for _, cid := range d.MissingProofs {
+			if !slices.Contains(cids, cid) {
+				cids = append(cids, cid)
+			}
+		}
+	})
+	return cids
+}
+
+// Walk calls fn for the delegation and every proof delegation in its chain
+func Walk(info *DelegationInfo, fn func(*DelegationInfo)) {
+	fn(info)
+	for _, pd := range info.ProofDelegations {
+		Walk(pd, fn)
+	}
+}
@@ -0,0 +1,54 @@
+package delegation
+
+import (
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofChainIssues(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	proof, err := MakeDelegation(service, operator, []string{capblob.AllocateAbility})
+	require.NoError(t, err)
+
+	t.Run("Valid", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		assert.Empty(t, ProofChainIssues(Inspect(d)))
+	})
+
+	t.Run("AudienceMismatch", func(t *testing.T) {
+		d, err := MakeDelegation(other, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		issues := ProofChainIssues(Inspect(d))
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0], proof.Link().String())
+		assert.Contains(t, issues[0], "not to the issuer")
+	})
+
+	t.Run("MissingProof", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(proof.Link())))
+		require.NoError(t, err)
+
+		issues := ProofChainIssues(Inspect(d))
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0], "is not included")
+	})
+}
@@ -0,0 +1,45 @@
+package delegation
+
+import (
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// PayloadCheck checks the payload of a delegation before it is signed, and
+// returns an error to refuse it
+type PayloadCheck func(payload ucan.UCAN) error
+
+// CheckingIssuer returns an issuer that signs with the provided one, but only
+// once the check accepts the payload of the delegation. Every function of
+// this package that issues a delegation, such as MakeDelegation, Attenuate,
+// Reissue, Renew or Builder.Build, builds the payload unsigned first and
+// returns the error of the check, without signing, when it refuses it. This
+// is how an issuance policy is enforced before a remote signer, such as an
+// agent, is asked for a signature.
+func CheckingIssuer(issuer ucan.Signer, check PayloadCheck) ucan.Signer {
+	return &checkingIssuer{Signer: issuer, check: check}
+}
+
+type checkingIssuer struct {
+	ucan.Signer
+	check PayloadCheck
+}
+
+// signerOf returns the signer behind a CheckingIssuer, or the issuer itself
+func signerOf(issuer ucan.Signer) ucan.Signer {
+	if ci, ok := issuer.(*checkingIssuer); ok {
+		return ci.Signer
+	}
+	return issuer
+}
+
+// verifierOf returns the verifier of the issuer key: that of a
+// principal.Signer, or of any signer with a Verifier method such as those of
+// the keys package
+func verifierOf(issuer ucan.Signer) (principal.Verifier, bool) {
+	vs, ok := signerOf(issuer).(interface{ Verifier() principal.Verifier })
+	if !ok {
+		return nil, false
+	}
+	return vs.Verifier(), true
+}
@@ -0,0 +1,118 @@
+package delegation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSigner counts the payloads it is asked to sign
+type countingSigner struct {
+	principal.Signer
+	signed int
+}
+
+func (s *countingSigner) Sign(msg []byte) signature.SignatureView {
+	s.signed++
+	return s.Signer.Sign(msg)
+}
+
+func TestCheckingIssuer(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	errRefused := errors.New("refused")
+	refuseAccept := func(payload ucan.UCAN) error {
+		if len(payload.Signature().Raw()) > 0 {
+			return errors.New("payload is signed")
+		}
+		for _, c := range payload.Capabilities() {
+			if c.Can() == capblob.AcceptAbility {
+				return errRefused
+			}
+		}
+		return nil
+	}
+
+	t.Run("MakeDelegation", func(t *testing.T) {
+		key := &countingSigner{Signer: service}
+		issuer := CheckingIssuer(key, refuseAccept)
+
+		_, err := MakeDelegation(issuer, operator, []string{capblob.AcceptAbility})
+		require.ErrorIs(t, err, errRefused)
+		assert.Zero(t, key.signed)
+
+		d, err := MakeDelegation(issuer, operator, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+		assert.Equal(t, 1, key.signed)
+		ok, err := VerifySignature(d.Data(), service.Verifier())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Builder", func(t *testing.T) {
+		key := &countingSigner{Signer: service}
+		_, err := New(CheckingIssuer(key, refuseAccept)).To(operator).Grant(capblob.AcceptAbility, service.DID().String(), nil).Build()
+		require.ErrorIs(t, err, errRefused)
+		assert.Zero(t, key.signed)
+	})
+
+	t.Run("Attenuate", func(t *testing.T) {
+		parent, err := MakeDelegation(service, operator, []string{"blob/*"})
+		require.NoError(t, err)
+		audience, err := ed25519.Generate()
+		require.NoError(t, err)
+
+		key := &countingSigner{Signer: operator}
+		_, err = Attenuate(CheckingIssuer(key, refuseAccept), audience, parent, []string{capblob.AcceptAbility}, nil)
+		require.ErrorIs(t, err, errRefused)
+		assert.Zero(t, key.signed)
+	})
+
+	t.Run("Renew", func(t *testing.T) {
+		old, err := MakeDelegation(service, operator, []string{capblob.AcceptAbility})
+		require.NoError(t, err)
+
+		key := &countingSigner{Signer: service}
+		_, err = Renew(CheckingIssuer(key, refuseAccept), old, int(time.Now().Add(time.Hour).Unix()))
+		require.ErrorIs(t, err, errRefused)
+		assert.Zero(t, key.signed)
+	})
+
+	t.Run("SignPayload", func(t *testing.T) {
+		d, err := MakeDelegation(UnsignedIssuer(service.DID()), operator, []string{capblob.AcceptAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		payload, err := EncodePayload(d)
+		require.NoError(t, err)
+
+		key := &countingSigner{Signer: service}
+		_, err = SignPayload(CheckingIssuer(key, refuseAccept), payload)
+		require.ErrorIs(t, err, errRefused)
+		assert.Zero(t, key.signed)
+	})
+
+	t.Run("DefaultExpiration", func(t *testing.T) {
+		// the signed delegation is the payload that was checked, including
+		// the expiration go-ucanto defaults to
+		var checked ucan.UCAN
+		d, err := MakeDelegation(CheckingIssuer(service, func(payload ucan.UCAN) error {
+			checked = payload
+			return nil
+		}), operator, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+		require.NotNil(t, checked)
+		assert.Equal(t, checked.Expiration(), d.Expiration())
+	})
+}
@@ -1,19 +1,27 @@
 package delegation
 
 import (
+	"bytes"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
 	"github.com/multiformats/go-multibase"
 	"github.com/multiformats/go-multicodec"
 	"github.com/multiformats/go-multihash"
-	"github.com/storacha/go-ucanto/core/dag/blockstore"
 	"github.com/storacha/go-ucanto/core/delegation"
 	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	udm "github.com/storacha/go-ucanto/ucan/datamodel/ucan"
 )
 
 func MakeDelegation(issuer ucan.Signer, audience ucan.Principal, capabilities []string, opts ...delegation.Option) (delegation.Delegation, error) {
@@ -26,7 +34,7 @@ func MakeDelegation(issuer ucan.Signer, audience ucan.Principal, capabilities []
 		)
 	}
 
-	return delegation.Delegate(
+	return delegate(
 		issuer,
 		audience,
 		uc,
@@ -34,6 +42,45 @@ func MakeDelegation(issuer ucan.Signer, audience ucan.Principal, capabilities []
 	)
 }
 
+// delegate is delegation.Delegate, refusing a delegation the issuer failed to
+// sign. The payload of a CheckingIssuer is built unsigned and checked first.
+func delegate[C ucan.CaveatBuilder](issuer ucan.Signer, audience ucan.Principal, capabilities []ucan.Capability[C], opts ...delegation.Option) (delegation.Delegation, error) {
+	if ci, ok := issuer.(*checkingIssuer); ok {
+		payload, err := delegate(UnsignedIssuer(ci.DID()), audience, capabilities, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := ci.check(payload); err != nil {
+			return nil, err
+		}
+		// Sign the payload that was checked, including the expiration that
+		// defaults to the time of issuance
+		if exp := payload.Expiration(); exp != nil {
+			opts = append(opts, delegation.WithExpiration(*exp))
+		}
+		return delegate(ci.Signer, audience, capabilities, opts...)
+	}
+
+	d, err := delegation.Delegate(issuer, audience, capabilities, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSigned(issuer, d.Signature()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// checkSigned returns ErrSigningFailed when a signature of the issuer is
+// empty, as signers that fail to reach their key leave it. Delegations of an
+// UnsignedIssuer are left unsigned on purpose.
+func checkSigned(issuer ucan.Signer, sig signature.SignatureView) error {
+	if _, ok := issuer.(unsignedIssuer); ok || len(sig.Raw()) > 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s produced an empty signature", ErrSigningFailed, issuer.DID())
+}
+
 // FormatDelegation takes a delegation archive from a read and returns a multibase-base64-encoded CIDv1 with
 // embedded CAR data.
 func FormatDelegation(d io.Reader) (string, error) {
@@ -70,97 +117,140 @@ func FormatDelegationBytes(archive []byte) (string, error) {
 
 // CapabilityInfo represents a capability in a delegation
 type CapabilityInfo struct {
-	With string `json:"with"`
-	Can  string `json:"can"`
+	With string          `json:"with"`
+	Can  string          `json:"can"`
+	Nb   json.RawMessage `json:"nb,omitempty"` // DAG-JSON encoded caveats
 }
 
 // DelegationInfo represents the structured information about a delegation
 type DelegationInfo struct {
-	Issuer           string                   `json:"issuer"`
-	Audience         string                   `json:"audience"`
-	Version          string                   `json:"version"`
-	Expiration       *int                     `json:"expiration,omitempty"` // Can be nil or an int
-	NotBefore        int                      `json:"notBefore"`
-	Nonce            string                   `json:"nonce,omitempty"`
-	Proofs           interface{}              `json:"proofs,omitempty"`           // Complex type from ucan library
-	ProofDelegations []*DelegationInfo        `json:"proofDelegations,omitempty"` // Parsed delegations from proofs
-	Signature        []byte                   `json:"signature"`
-	Capabilities     []CapabilityInfo         `json:"capabilities"`
-	Facts            []map[string]interface{} `json:"facts,omitempty"`
-}
-
-// parseDelegationToDelegationInfo converts a ucanto delegation.Delegation to DelegationInfo
-func parseDelegationToDelegationInfo(deleg delegation.Delegation) *DelegationInfo {
-	// Build result struct with detail
-	result := &DelegationInfo{
-		Issuer:     deleg.Issuer().DID().String(),
-		Audience:   deleg.Audience().DID().String(),
-		Version:    deleg.Version(),
-		Expiration: deleg.Expiration(),
-		NotBefore:  deleg.NotBefore(),
-		Nonce:      deleg.Nonce(),
-		Proofs:     deleg.Proofs(),
-		Signature:  deleg.Signature().Bytes(),
-	}
-
-	// Extract capabilities
-	for _, c := range deleg.Capabilities() {
-		capInfo := CapabilityInfo{
-			With: c.With(),
-			Can:  c.Can(),
-		}
-		result.Capabilities = append(result.Capabilities, capInfo)
-	}
-
-	// Extract facts
-	for _, f := range deleg.Facts() {
-		result.Facts = append(result.Facts, f)
-	}
-
-	// Process proofs recursively
-	if len(deleg.Proofs()) > 0 {
-		br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(deleg.Blocks()))
-		if err == nil {
-			proofs := delegation.NewProofsView(deleg.Proofs(), br)
-			for _, proof := range proofs {
-				pd, ok := proof.Delegation()
-				if !ok {
-					continue
-				}
-				// Recursively parse the delegation from the proof
-				proofDelegInfo := parseDelegationToDelegationInfo(pd)
-				result.ProofDelegations = append(result.ProofDelegations, proofDelegInfo)
-			}
-		}
-	}
+	CID              string            `json:"cid"`
+	Issuer           string            `json:"issuer"`
+	Audience         string            `json:"audience"`
+	Version          string            `json:"version"`
+	Expiration       *int              `json:"expiration,omitempty"` // Can be nil or an int
+	NotBefore        int               `json:"notBefore"`
+	Nonce            string            `json:"nonce,omitempty"`
+	Proofs           []json.RawMessage `json:"proofs,omitempty"`           // DAG-JSON encoded proof links
+	ProofDelegations []*DelegationInfo `json:"proofDelegations,omitempty"` // Parsed delegations from proofs
+	MissingProofs    []string          `json:"missingProofs,omitempty"`    // CIDs of proofs not included in the archive
+	DuplicateProofs  []string          `json:"duplicateProofs,omitempty"`  // CIDs of proofs inspected elsewhere in the chain
+	ProofErrors      []string          `json:"proofErrors,omitempty"`      // Proofs included in the archive that could not be read
+	ErroredProofs    []string          `json:"erroredProofs,omitempty"`    // CIDs of the proofs reported in ProofErrors
+	Truncated        bool              `json:"truncated,omitempty"`        // Proofs not inspected beyond the maximum depth
+	ExternalProofs   []string          `json:"externalProofs,omitempty"`   // CIDs of proofs resolved from proof sources rather than the archive
+	Incomplete       bool              `json:"incomplete,omitempty"`       // Proofs of the chain are missing or could not be read
+	Signature        []byte            `json:"signature"`
+	Capabilities     []CapabilityInfo  `json:"capabilities"`
+	Facts            []json.RawMessage `json:"facts,omitempty"`          // DAG-JSON encoded facts
+	EncodingErrors   []string          `json:"encodingErrors,omitempty"` // Caveats, facts and proof links that could not be encoded, and are left out
 
-	return result
+	// Set by AnnotateExpiry
+	Status              ExpiryStatus `json:"status,omitempty"`
+	EffectiveExpiration *int         `json:"effectiveExpiration,omitempty"` // Earliest expiration in the proof chain
 }
 
-// ParseDelegationContent parses delegation content from a string and returns information about it
-func ParseDelegationContent(content string) (*DelegationInfo, error) {
+// DecodeDelegation decodes a delegation from its multibase-base64-encoded CID form, or from the
+// base64 encoding of that form. Failures are reported as a *DecodeError matching ErrInvalidEncoding
+// or ErrInvalidArchive.
+func DecodeDelegation(content string) (delegation.Delegation, error) {
+	return decodeContent(content, delegation.Extract)
+}
+
+// decodeContent decodes content in the CID form, or the base64 encoding of
+// that form, extracting the archive it holds with extract
+func decodeContent[T any](content string, extract func([]byte) (T, error)) (T, error) {
+	var zero T
 	// Trim any whitespace
 	content = strings.TrimSpace(content)
 
-	// Parse the delegation using go-ucanto library
-	deleg, err := delegation.Parse(content)
+	v, cidErr := parseCID(content, extract)
+	if cidErr == nil {
+		return v, nil
+	}
+	// Content that is a CAR CID holds an invalid archive, there is nothing to fall back to
+	if !errors.Is(cidErr, ErrInvalidEncoding) {
+		return zero, &DecodeError{CID: cidErr}
+	}
+
+	// If parsing fails, try to decode it from base64 first
+	decoded, err := base64.StdEncoding.DecodeString(content)
 	if err != nil {
-		// If parsing fails, try to decode it from base64 first
-		decoded, err := base64.StdEncoding.DecodeString(content)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode base64 content: %w", err)
-		}
+		return zero, &DecodeError{CID: cidErr, Base64: fmt.Errorf("%w: %w", errNotBase64, err)}
+	}
+	v, err = parseCID(strings.TrimSpace(string(decoded)), extract)
+	if err != nil {
+		return zero, &DecodeError{CID: cidErr, Base64: err}
+	}
+	return v, nil
+}
 
-		deleg, err = delegation.Parse(string(decoded))
-		if err != nil {
-			return nil, fmt.Errorf("failed to import delegation from decoded content: %w", err)
-		}
+// parseCID extracts the archive of the multibase-base64-encoded CID form of a
+// delegation, as delegation.Parse does, telling encoding failures from
+// archive ones
+func parseCID[T any](content string, extract func([]byte) (T, error)) (T, error) {
+	var zero T
+	link, err := cid.Decode(content)
+	if err != nil {
+		return zero, fmt.Errorf("%w: decoding CID: %w", ErrInvalidEncoding, err)
+	}
+	if codec := multicodec.Code(link.Prefix().Codec); codec != multicodec.Car {
+		return zero, fmt.Errorf("%w: non CAR codec found: %s", ErrInvalidEncoding, codec)
+	}
+	mh, err := multihash.Decode(link.Hash())
+	if err != nil {
+		return zero, fmt.Errorf("%w: decoding multihash: %w", ErrInvalidEncoding, err)
+	}
+	if code := multicodec.Code(mh.Code); code != multicodec.Identity {
+		return zero, fmt.Errorf("%w: non identity multihash: %s", ErrInvalidEncoding, code)
+	}
+
+	v, err := extract(mh.Digest)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	return v, nil
+}
+
+// LoadDelegation reads a delegation from a file and decodes it
+func LoadDelegation(filePath string) (delegation.Delegation, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delegation file: %w", err)
 	}
 
-	// Use the helper function to parse delegation recursively
-	result := parseDelegationToDelegationInfo(deleg)
+	return DecodeDelegation(string(data))
+}
+
+// encodeDAGJSON encodes an IPLD node as DAG-JSON
+func encodeDAGJSON(n datamodel.Node) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	if err := dagjson.Encode(n, &buf); err != nil {
+		return nil, fmt.Errorf("failed to encode DAG-JSON: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeFact encodes a fact as a DAG-JSON map
+func encodeFact(f udm.FactModel) (json.RawMessage, error) {
+	n, err := qp.BuildMap(basicnode.Prototype.Any, int64(len(f.Keys)), func(ma datamodel.MapAssembler) {
+		for _, k := range f.Keys {
+			qp.MapEntry(ma, k, qp.Node(f.Values[k]))
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build fact: %w", err)
+	}
+	return encodeDAGJSON(n)
+}
 
-	return result, nil
+// ParseDelegationContent parses delegation content from a string and returns information about it
+func ParseDelegationContent(content string, opts ...InspectOption) (*DelegationInfo, error) {
+	deleg, err := DecodeDelegation(content)
+	if err != nil {
+		return nil, err
+	}
+	return Inspect(deleg, opts...), nil
 }
 
 // ParseDelegation reads a delegation from a file and returns information about it
@@ -1,15 +1,20 @@
 package delegation
 
 import (
+	"encoding/json"
 	"io"
 	"testing"
 
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
 	capassert "github.com/storacha/go-libstoracha/capabilities/assert"
 	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
 	capreplica "github.com/storacha/go-libstoracha/capabilities/blob/replica"
 	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
 
+	"github.com/storacha/go-ucanto/core/delegation"
 	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -132,3 +137,51 @@ func TestDelegationRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+type testFact map[string]datamodel.Node
+
+func (f testFact) ToIPLD() (map[string]datamodel.Node, error) {
+	return f, nil
+}
+
+func TestFactsAndProofsAsDAGJSON(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	proof, err := MakeDelegation(issuer, audience, []string{capclaim.CacheAbility})
+	require.NoError(t, err)
+
+	fact := testFact{
+		"link":  basicnode.NewLink(proof.Link()),
+		"bytes": basicnode.NewBytes([]byte{1, 2, 3}),
+		"name":  basicnode.NewString("test"),
+	}
+	deleg, err := MakeDelegation(issuer, audience, []string{capclaim.CacheAbility},
+		delegation.WithFacts([]ucan.FactBuilder{fact}),
+		delegation.WithProof(delegation.FromDelegation(proof)),
+	)
+	require.NoError(t, err)
+
+	b64, err := FormatDelegation(deleg.Archive())
+	require.NoError(t, err)
+
+	info, err := ParseDelegationContent(b64)
+	require.NoError(t, err)
+
+	require.Len(t, info.Proofs, 1)
+	assert.JSONEq(t, `{"/":"`+proof.Link().String()+`"}`, string(info.Proofs[0]))
+
+	require.Len(t, info.Facts, 1)
+	assert.JSONEq(t, `{
+		"link": {"/": "`+proof.Link().String()+`"},
+		"bytes": {"/": {"bytes": "AQID"}},
+		"name": "test"
+	}`, string(info.Facts[0]))
+
+	out, err := json.Marshal(info)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"proofs":[{"/":"`+proof.Link().String()+`"}]`)
+}
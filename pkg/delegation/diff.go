@@ -0,0 +1,157 @@
+package delegation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// ChangeKind describes how a field differs between two delegations
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change represents a single difference between two delegations
+type Change struct {
+	Field string     `json:"field"`
+	Kind  ChangeKind `json:"kind"`
+	Old   string     `json:"old,omitempty"`
+	New   string     `json:"new,omitempty"`
+}
+
+// Diff compares two delegations field by field and returns the differences
+// between them. Capabilities are matched by ability and resource, so a
+// capability whose caveats differ is reported as changed, and the caveats of
+// an ability and resource granted several times are compared as a multiset.
+// Facts and proofs are compared as sets.
+func Diff(old, new *DelegationInfo) []Change {
+	var changes []Change
+
+	compare := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, Change{Field: field, Kind: ChangeChanged, Old: o, New: n})
+		}
+	}
+	compare("issuer", old.Issuer, new.Issuer)
+	compare("audience", old.Audience, new.Audience)
+	compare("version", old.Version, new.Version)
+	compare("expiration", formatExpiration(old.Expiration), formatExpiration(new.Expiration))
+	compare("notBefore", strconv.Itoa(old.NotBefore), strconv.Itoa(new.NotBefore))
+	compare("nonce", old.Nonce, new.Nonce)
+
+	changes = append(changes, diffCapabilities(old.Capabilities, new.Capabilities)...)
+	changes = append(changes, diffSets("fact", factKeys(old.Facts), factKeys(new.Facts))...)
+	changes = append(changes, diffSets("proof", proofCIDs(old), proofCIDs(new))...)
+
+	return changes
+}
+
+func diffCapabilities(old, new []CapabilityInfo) []Change {
+	key := func(c CapabilityInfo) string {
+		return fmt.Sprintf("capability %s with %s", c.Can, c.With)
+	}
+	// Caveats of the capabilities with each ability and resource, which may
+	// be granted several times with different caveats
+	var keys []string
+	oldNbs := map[string][]string{}
+	newNbs := map[string][]string{}
+	for _, c := range old {
+		k := key(c)
+		if _, ok := oldNbs[k]; !ok {
+			keys = append(keys, k)
+		}
+		oldNbs[k] = append(oldNbs[k], string(c.Nb))
+	}
+	for _, c := range new {
+		k := key(c)
+		if _, ok := oldNbs[k]; !ok && newNbs[k] == nil {
+			keys = append(keys, k)
+		}
+		newNbs[k] = append(newNbs[k], string(c.Nb))
+	}
+
+	var changes []Change
+	for _, k := range keys {
+		// Caveats in both are unchanged, the others are paired up as changed
+		// and the rest are removed or added
+		removed := slices.Clone(oldNbs[k])
+		var added []string
+		for _, nb := range newNbs[k] {
+			if i := slices.Index(removed, nb); i >= 0 {
+				removed = slices.Delete(removed, i, i+1)
+			} else {
+				added = append(added, nb)
+			}
+		}
+		for len(removed) > 0 && len(added) > 0 {
+			changes = append(changes, Change{Field: k, Kind: ChangeChanged, Old: removed[0], New: added[0]})
+			removed, added = removed[1:], added[1:]
+		}
+		for _, nb := range removed {
+			changes = append(changes, Change{Field: k, Kind: ChangeRemoved, Old: nb})
+		}
+		for _, nb := range added {
+			changes = append(changes, Change{Field: k, Kind: ChangeAdded, New: nb})
+		}
+	}
+	return changes
+}
+
+func diffSets(field string, old, new []string) []Change {
+	inOld := map[string]bool{}
+	for _, v := range old {
+		inOld[v] = true
+	}
+	inNew := map[string]bool{}
+	for _, v := range new {
+		inNew[v] = true
+	}
+
+	var changes []Change
+	for _, v := range old {
+		if !inNew[v] {
+			changes = append(changes, Change{Field: field, Kind: ChangeRemoved, Old: v})
+		}
+	}
+	for _, v := range new {
+		if !inOld[v] {
+			changes = append(changes, Change{Field: field, Kind: ChangeAdded, New: v})
+		}
+	}
+	return changes
+}
+
+func formatExpiration(exp *int) string {
+	if exp == nil {
+		return "none"
+	}
+	return strconv.Itoa(*exp)
+}
+
+func factKeys(facts []json.RawMessage) []string {
+	keys := make([]string, 0, len(facts))
+	for _, f := range facts {
+		keys = append(keys, string(f))
+	}
+	return keys
+}
+
+func proofCIDs(info *DelegationInfo) []string {
+	cids := make([]string, 0, len(info.Proofs))
+	for _, p := range info.Proofs {
+		var link struct {
+			CID string `json:"/"`
+		}
+		if err := json.Unmarshal(p, &link); err != nil {
+			cids = append(cids, string(p))
+			continue
+		}
+		cids = append(cids, link.CID)
+	}
+	return cids
+}
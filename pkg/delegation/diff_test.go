@@ -0,0 +1,96 @@
+package delegation
+
+import (
+	"encoding/json"
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("Identical", func(t *testing.T) {
+		issuer, err := ed25519.Generate()
+		require.NoError(t, err)
+		audience, err := ed25519.Generate()
+		require.NoError(t, err)
+
+		d, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		assert.Empty(t, Diff(info, info))
+	})
+
+	t.Run("Rotated", func(t *testing.T) {
+		oldIssuer, err := ed25519.Generate()
+		require.NoError(t, err)
+		newIssuer, err := ed25519.Generate()
+		require.NoError(t, err)
+		audience, err := ed25519.Generate()
+		require.NoError(t, err)
+
+		oldDeleg, err := MakeDelegation(oldIssuer, audience, []string{capblob.AllocateAbility, capblob.AcceptAbility}, delegation.WithExpiration(1000))
+		require.NoError(t, err)
+		newDeleg, err := MakeDelegation(newIssuer, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+
+		changes := Diff(Inspect(oldDeleg), Inspect(newDeleg))
+		assert.Contains(t, changes, Change{Field: "issuer", Kind: ChangeChanged, Old: oldIssuer.DID().String(), New: newIssuer.DID().String()})
+		assert.Contains(t, changes, Change{Field: "expiration", Kind: ChangeChanged, Old: "1000", New: "none"})
+		assert.Contains(t, changes, Change{Field: "capability blob/accept with " + oldIssuer.DID().String(), Kind: ChangeRemoved, Old: "{}"})
+		assert.Contains(t, changes, Change{Field: "capability blob/allocate with " + newIssuer.DID().String(), Kind: ChangeAdded, New: "{}"})
+	})
+
+	t.Run("CaveatsAndProofs", func(t *testing.T) {
+		old := &DelegationInfo{
+			Capabilities: []CapabilityInfo{{Can: "blob/allocate", With: "did:key:a", Nb: json.RawMessage(`{"size":1}`)}},
+		}
+		new := &DelegationInfo{
+			Capabilities: []CapabilityInfo{{Can: "blob/allocate", With: "did:key:a", Nb: json.RawMessage(`{"size":2}`)}},
+		}
+
+		changes := Diff(old, new)
+		require.Len(t, changes, 1)
+		assert.Equal(t, Change{Field: "capability blob/allocate with did:key:a", Kind: ChangeChanged, Old: `{"size":1}`, New: `{"size":2}`}, changes[0])
+	})
+
+	t.Run("RepeatedCapability", func(t *testing.T) {
+		capability := func(nb string) CapabilityInfo {
+			return CapabilityInfo{Can: "blob/allocate", With: "did:key:a", Nb: json.RawMessage(nb)}
+		}
+		old := &DelegationInfo{Capabilities: []CapabilityInfo{capability(`{"size":1}`), capability(`{"size":2}`)}}
+
+		// reordering is not a change
+		assert.Empty(t, Diff(old, &DelegationInfo{Capabilities: []CapabilityInfo{capability(`{"size":2}`), capability(`{"size":1}`)}}))
+
+		changes := Diff(old, &DelegationInfo{Capabilities: []CapabilityInfo{capability(`{"size":1}`), capability(`{"size":3}`)}})
+		assert.Equal(t, []Change{{Field: "capability blob/allocate with did:key:a", Kind: ChangeChanged, Old: `{"size":2}`, New: `{"size":3}`}}, changes)
+
+		changes = Diff(old, &DelegationInfo{Capabilities: []CapabilityInfo{capability(`{"size":2}`)}})
+		assert.Equal(t, []Change{{Field: "capability blob/allocate with did:key:a", Kind: ChangeRemoved, Old: `{"size":1}`}}, changes)
+	})
+
+	t.Run("ProofChain", func(t *testing.T) {
+		root, err := ed25519.Generate()
+		require.NoError(t, err)
+		issuer, err := ed25519.Generate()
+		require.NoError(t, err)
+		audience, err := ed25519.Generate()
+		require.NoError(t, err)
+
+		proof, err := MakeDelegation(root, issuer, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		withoutProof, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		withProof, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration(), delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		changes := Diff(Inspect(withoutProof), Inspect(withProof))
+		assert.Equal(t, []Change{{Field: "proof", Kind: ChangeAdded, New: proof.Link().String()}}, changes)
+	})
+}
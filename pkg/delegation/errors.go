@@ -0,0 +1,131 @@
+package delegation
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidEncoding is returned when content is neither the
+	// multibase-base64-encoded CID form of a delegation nor its base64 encoding
+	ErrInvalidEncoding = errors.New("invalid delegation encoding")
+	// ErrInvalidArchive is returned when the CAR archive of a delegation
+	// cannot be decoded, or does not hold a delegation
+	ErrInvalidArchive = errors.New("invalid delegation archive")
+	// ErrInvalidSignature is returned when a signature is malformed or was not
+	// produced by the key of the issuer
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUnknownCapability is matched by UnknownCapabilityError
+	ErrUnknownCapability = errors.New("unknown capability")
+	// ErrSigningFailed is returned when a signer that may fail to sign, such
+	// as a remote signer, produced an empty signature
+	ErrSigningFailed = errors.New("signing failed")
+	// ErrExpired is matched by a TimeBoundsError of an expired delegation
+	ErrExpired = errors.New("delegation expired")
+	// ErrNotYetValid is matched by a TimeBoundsError of a delegation that is
+	// not valid yet
+	ErrNotYetValid = errors.New("delegation not yet valid")
+	// ErrInvalidProofChain is returned for a proof chain that is broken,
+	// incomplete or was not fully verified
+	ErrInvalidProofChain = errors.New("invalid proof chain")
+	// ErrProofsRequired is returned when a delegation with proofs is reissued
+	// under a new issuer DID without proofs delegated to the new issuer
+	ErrProofsRequired = errors.New("proofs for the new issuer required")
+)
+
+// UnknownCapabilityError is returned when an ability is not in the set of
+// known capabilities
+type UnknownCapabilityError struct {
+	Ability string
+}
+
+func (e *UnknownCapabilityError) Error() string {
+	return fmt.Sprintf("unknown capability: %s", e.Ability)
+}
+
+func (e *UnknownCapabilityError) Is(target error) bool {
+	return target == ErrUnknownCapability
+}
+
+// SignatureError is returned when the signature of a delegation is not valid
+// for its issuer, or cannot be verified. It matches ErrInvalidSignature.
+type SignatureError struct {
+	// CID is the CID of the delegation
+	CID string
+	// Issuer is the DID of the issuer of the delegation
+	Issuer string
+	// Err is the reason the signature cannot be verified, nil when it was
+	// verified and is not valid
+	Err error
+}
+
+func (e *SignatureError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("signature of %s cannot be verified: %s", e.CID, e.Err)
+	}
+	return fmt.Sprintf("signature of %s is not valid for issuer %s", e.CID, e.Issuer)
+}
+
+func (e *SignatureError) Is(target error) bool {
+	return target == ErrInvalidSignature
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// TimeBoundsError is returned when a delegation is used outside of its time
+// bounds. It matches ErrExpired or ErrNotYetValid, according to its status.
+type TimeBoundsError struct {
+	// CID is the CID of the delegation
+	CID string
+	// Status is StatusExpired or StatusNotYetValid
+	Status ExpiryStatus
+	// At is the expiration, or the not-before time, of the delegation
+	At time.Time
+}
+
+func (e *TimeBoundsError) Error() string {
+	if e.Status == StatusExpired {
+		return fmt.Sprintf("%s expired at %s", e.CID, e.At.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s is not valid before %s", e.CID, e.At.UTC().Format(time.RFC3339))
+}
+
+func (e *TimeBoundsError) Is(target error) bool {
+	return (target == ErrExpired && e.Status == StatusExpired) ||
+		(target == ErrNotYetValid && e.Status == StatusNotYetValid)
+}
+
+// DecodeError is returned when content cannot be decoded as a delegation. It
+// holds the failure of decoding the content as the CID form of a delegation
+// and, when that fails because the content is not a CID, the failure of
+// decoding it as the base64 encoding of one.
+type DecodeError struct {
+	// CID is the failure of decoding the content as the CID form
+	CID error
+	// Base64 is the failure of decoding the content as the base64 encoding of
+	// the CID form, nil when it was not tried
+	Base64 error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Base64 == nil {
+		return e.CID.Error()
+	}
+	return fmt.Sprintf("%s; as base64: %s", e.CID, e.Base64)
+}
+
+// Unwrap returns the failure of the attempt that got furthest, so that
+// errors.Is reports ErrInvalidArchive for base64 encoded content holding an
+// invalid archive, rather than the failure to decode it as a CID.
+func (e *DecodeError) Unwrap() error {
+	if e.Base64 != nil && !errors.Is(e.Base64, errNotBase64) {
+		return e.Base64
+	}
+	return e.CID
+}
+
+// errNotBase64 is the encoding error of content that is not base64
+var errNotBase64 = fmt.Errorf("%w: not base64", ErrInvalidEncoding)
@@ -0,0 +1,117 @@
+package delegation
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multibase"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeErrors(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	d, err := MakeDelegation(issuer, issuer, []string{capblob.AllocateAbility})
+	require.NoError(t, err)
+	valid, err := FormatDelegation(d.Archive())
+	require.NoError(t, err)
+
+	// a CAR CID whose identity digest is not a CAR archive
+	mh, err := multihash.Sum([]byte("not a CAR"), multihash.IDENTITY, -1)
+	require.NoError(t, err)
+	badArchive, err := cid.NewCidV1(uint64(multicodec.Car), mh).StringOfBase(multibase.Base64)
+	require.NoError(t, err)
+
+	t.Run("Valid", func(t *testing.T) {
+		for _, content := range []string{valid, base64.StdEncoding.EncodeToString([]byte(valid))} {
+			decoded, err := DecodeDelegation(content)
+			require.NoError(t, err)
+			assert.Equal(t, d.Link(), decoded.Link())
+		}
+	})
+
+	t.Run("NotBase64", func(t *testing.T) {
+		_, err := DecodeDelegation("not a delegation!")
+		require.ErrorIs(t, err, ErrInvalidEncoding)
+		assert.NotErrorIs(t, err, ErrInvalidArchive)
+
+		var derr *DecodeError
+		require.ErrorAs(t, err, &derr)
+		require.Error(t, derr.CID)
+		require.Error(t, derr.Base64)
+		assert.Contains(t, err.Error(), "decoding CID")
+		assert.Contains(t, err.Error(), "not base64")
+	})
+
+	t.Run("Base64NotCID", func(t *testing.T) {
+		_, err := DecodeDelegation(base64.StdEncoding.EncodeToString([]byte("hello")))
+		require.ErrorIs(t, err, ErrInvalidEncoding)
+
+		var derr *DecodeError
+		require.ErrorAs(t, err, &derr)
+		assert.NotErrorIs(t, derr.Base64, errNotBase64)
+	})
+
+	t.Run("InvalidArchive", func(t *testing.T) {
+		_, err := DecodeDelegation(badArchive)
+		require.ErrorIs(t, err, ErrInvalidArchive)
+		assert.NotErrorIs(t, err, ErrInvalidEncoding)
+
+		var derr *DecodeError
+		require.ErrorAs(t, err, &derr)
+		assert.Nil(t, derr.Base64)
+	})
+
+	t.Run("Base64InvalidArchive", func(t *testing.T) {
+		_, err := DecodeDelegation(base64.StdEncoding.EncodeToString([]byte(badArchive)))
+		require.ErrorIs(t, err, ErrInvalidArchive)
+		assert.NotErrorIs(t, err, ErrInvalidEncoding)
+
+		// both failure reasons are preserved
+		var derr *DecodeError
+		require.ErrorAs(t, err, &derr)
+		require.ErrorIs(t, derr.CID, ErrInvalidEncoding)
+		require.ErrorIs(t, derr.Base64, ErrInvalidArchive)
+
+		_, err = ParseDelegationContent(base64.StdEncoding.EncodeToString([]byte(badArchive)))
+		require.ErrorIs(t, err, ErrInvalidArchive)
+	})
+}
+
+func TestUnknownCapabilityError(t *testing.T) {
+	err := fmt.Errorf("validating: %w", &UnknownCapabilityError{Ability: "blob/frobnicate"})
+	require.ErrorIs(t, err, ErrUnknownCapability)
+
+	var uerr *UnknownCapabilityError
+	require.ErrorAs(t, err, &uerr)
+	assert.Equal(t, "blob/frobnicate", uerr.Ability)
+	assert.False(t, errors.Is(err, ErrInvalidEncoding))
+}
+
+func TestInvalidSignatureError(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	d, err := MakeDelegation(UnsignedIssuer(issuer.DID()), issuer, []string{capblob.AllocateAbility})
+	require.NoError(t, err)
+	payload, err := EncodePayload(d)
+	require.NoError(t, err)
+
+	_, err = Assemble(payload, []byte{0xed, 0x01}, issuer.Verifier())
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	sig, err := SignPayload(issuer, payload)
+	require.NoError(t, err)
+	sig[len(sig)-1] ^= 0xff
+	_, err = Assemble(payload, sig, issuer.Verifier())
+	require.ErrorIs(t, err, ErrInvalidSignature)
+}
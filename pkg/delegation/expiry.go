@@ -0,0 +1,58 @@
+package delegation
+
+import (
+	"time"
+)
+
+// ExpiryStatus describes the validity of a delegation at a point in time
+type ExpiryStatus string
+
+const (
+	StatusValid       ExpiryStatus = "valid"
+	StatusExpiring    ExpiryStatus = "expiring"
+	StatusExpired     ExpiryStatus = "expired"
+	StatusNotYetValid ExpiryStatus = "not-yet-valid"
+)
+
+// CheckExpiry returns the status of a delegation at the provided time. A
+// delegation that expires within the warning window is reported as expiring.
+func CheckExpiry(info *DelegationInfo, now time.Time, warnWithin time.Duration) ExpiryStatus {
+	ts := int(now.Unix())
+	switch {
+	case info.Expiration != nil && *info.Expiration <= ts:
+		return StatusExpired
+	case info.NotBefore != 0 && ts < info.NotBefore:
+		return StatusNotYetValid
+	case info.Expiration != nil && time.Unix(int64(*info.Expiration), 0).Sub(now) <= warnWithin:
+		return StatusExpiring
+	default:
+		return StatusValid
+	}
+}
+
+// EffectiveExpiration returns the earliest expiration of the delegation and
+// the proof delegations in its chain, or nil when none of them expire
+func EffectiveExpiration(info *DelegationInfo) *int {
+	exp := info.Expiration
+	for _, pd := range info.ProofDelegations {
+		pexp := EffectiveExpiration(pd)
+		if pexp != nil && (exp == nil || *pexp < *exp) {
+			exp = pexp
+		}
+	}
+	if exp == nil {
+		return nil
+	}
+	effective := *exp
+	return &effective
+}
+
+// AnnotateExpiry sets the status and the effective expiration of the
+// delegation and of each proof delegation in its chain
+func AnnotateExpiry(info *DelegationInfo, now time.Time, warnWithin time.Duration) {
+	info.Status = CheckExpiry(info, now, warnWithin)
+	info.EffectiveExpiration = EffectiveExpiration(info)
+	for _, pd := range info.ProofDelegations {
+		AnnotateExpiry(pd, now, warnWithin)
+	}
+}
@@ -0,0 +1,75 @@
+package delegation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	week := 7 * 24 * time.Hour
+	at := func(d time.Duration) *int {
+		ts := int(now.Add(d).Unix())
+		return &ts
+	}
+
+	testCases := []struct {
+		name     string
+		info     *DelegationInfo
+		expected ExpiryStatus
+	}{
+		{"NoExpiration", &DelegationInfo{}, StatusValid},
+		{"FarFuture", &DelegationInfo{Expiration: at(30 * 24 * time.Hour)}, StatusValid},
+		{"ExpiringSoon", &DelegationInfo{Expiration: at(24 * time.Hour)}, StatusExpiring},
+		{"Expired", &DelegationInfo{Expiration: at(-time.Second)}, StatusExpired},
+		{"ExpiresNow", &DelegationInfo{Expiration: at(0)}, StatusExpired},
+		{"NotYetValid", &DelegationInfo{NotBefore: *at(time.Hour)}, StatusNotYetValid},
+		{"NotBeforeNow", &DelegationInfo{NotBefore: *at(0)}, StatusValid},
+		{"NotBeforeNextSecond", &DelegationInfo{NotBefore: *at(time.Second)}, StatusNotYetValid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CheckExpiry(tc.info, now, week))
+		})
+	}
+}
+
+func TestEffectiveExpiration(t *testing.T) {
+	exp := func(ts int) *int { return &ts }
+
+	t.Run("NoExpiration", func(t *testing.T) {
+		info := &DelegationInfo{ProofDelegations: []*DelegationInfo{{}}}
+		assert.Nil(t, EffectiveExpiration(info))
+	})
+
+	t.Run("MinimumAcrossProofs", func(t *testing.T) {
+		info := &DelegationInfo{
+			Expiration: exp(300),
+			ProofDelegations: []*DelegationInfo{
+				{Expiration: exp(400)},
+				{ProofDelegations: []*DelegationInfo{{Expiration: exp(200)}}},
+			},
+		}
+		effective := EffectiveExpiration(info)
+		require.NotNil(t, effective)
+		assert.Equal(t, 200, *effective)
+	})
+
+	t.Run("Annotate", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		info := &DelegationInfo{
+			Expiration:       exp(100_000_000),
+			ProofDelegations: []*DelegationInfo{{Expiration: exp(500)}},
+		}
+		AnnotateExpiry(info, now, time.Hour)
+
+		assert.Equal(t, StatusValid, info.Status)
+		require.NotNil(t, info.EffectiveExpiration)
+		assert.Equal(t, 500, *info.EffectiveExpiration)
+		assert.Equal(t, StatusExpired, info.ProofDelegations[0].Status)
+	})
+}
@@ -0,0 +1,113 @@
+package delegation
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+)
+
+// ExtractProof returns a proof from the chain of a delegation, identified by
+// ref: either the CID of a proof anywhere in the chain, or an index path such
+// as "1.2" for the second proof of the first proof of the delegation. The
+// chain is walked as Inspect walks it, so indexes start at 1 and number the
+// proof delegations as parse does: proofs that are not included in the
+// archive, or that already appear earlier in the chain, are not numbered.
+// The proof holds only its own blocks and those of its proofs, so its
+// archive can be verified or used on its own.
+func ExtractProof(deleg delegation.Delegation, ref string) (delegation.Delegation, error) {
+	views := map[string]delegation.Delegation{}
+	info := inspect(deleg, func(d delegation.Delegation) {
+		views[d.Link().String()] = d
+	}, WithCaveats(false), WithMaxDepth(-1))
+
+	var proof *DelegationInfo
+	var err error
+	if isIndexPath(ref) {
+		proof, err = proofAtPath(info, ref)
+	} else {
+		proof, err = proofWithCID(info, ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	view := views[proof.CID]
+
+	// Keep the blocks the proof depends on, rather than the whole archive
+	var blocks []ipld.Block
+	for b, err := range view.Export() {
+		if err != nil {
+			return nil, fmt.Errorf("exporting proof %s: %w", view.Link(), err)
+		}
+		blocks = append(blocks, b)
+	}
+	bs, err := blockstore.NewBlockReader(blockstore.WithBlocks(blocks))
+	if err != nil {
+		return nil, fmt.Errorf("reading proof blocks: %w", err)
+	}
+	return delegation.NewDelegationView(view.Link(), bs)
+}
+
+// isIndexPath reports whether ref is made of dot separated numbers
+func isIndexPath(ref string) bool {
+	for _, part := range strings.Split(ref, ".") {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func proofAtPath(info *DelegationInfo, path string) (*DelegationInfo, error) {
+	cur := info
+	for depth, part := range strings.Split(path, ".") {
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("parsing index path %s: %w", path, err)
+		}
+		proofs := cur.ProofDelegations
+		if i < 1 || i > len(proofs) {
+			err := fmt.Errorf("no proof %d at depth %d of index path %s: %s has %d proofs in the archive", i, depth+1, path, cur.CID, len(proofs))
+			if len(cur.MissingProofs) > 0 || len(cur.ProofErrors) > 0 {
+				err = fmt.Errorf("%w, and proofs that are not included in the archive or cannot be read: %s", err, strings.Join(append(slices.Clone(cur.MissingProofs), cur.ProofErrors...), "; "))
+			}
+			return nil, err
+		}
+		cur = proofs[i-1]
+	}
+	return cur, nil
+}
+
+func proofWithCID(info *DelegationInfo, ref string) (*DelegationInfo, error) {
+	c, err := cid.Decode(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a CID nor an index path: %w", ref, err)
+	}
+	want := cidlink.Link{Cid: c}.String()
+
+	var proof *DelegationInfo
+	var missing, errored bool
+	Walk(info, func(d *DelegationInfo) {
+		if d != info && d.CID == want && proof == nil {
+			proof = d
+		}
+		missing = missing || slices.Contains(d.MissingProofs, want)
+		errored = errored || slices.Contains(d.ErroredProofs, want)
+	})
+	switch {
+	case proof != nil:
+		return proof, nil
+	case missing:
+		return nil, fmt.Errorf("proof %s is not included in the archive", want)
+	case errored:
+		return nil, fmt.Errorf("proof %s cannot be read from the archive", want)
+	default:
+		return nil, fmt.Errorf("proof %s is not in the chain of %s", ref, info.CID)
+	}
+}
@@ -0,0 +1,120 @@
+package delegation
+
+import (
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractProof(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	node, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	root, err := New(service).To(operator).Grant("blob/*", service.DID().String(), nil).Build()
+	require.NoError(t, err)
+	other, err := New(service).To(operator).Grant("blob/*", service.DID().String(), nil).WithNonce("other").Build()
+	require.NoError(t, err)
+	middle, err := New(operator).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(other, root).Build()
+	require.NoError(t, err)
+	leaf, err := New(node).To(audience).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(middle).Build()
+	require.NoError(t, err)
+
+	blockCount := func(d delegation.Delegation) int {
+		n := 0
+		for _, err := range d.Blocks() {
+			require.NoError(t, err)
+			n++
+		}
+		return n
+	}
+
+	t.Run("IndexPath", func(t *testing.T) {
+		for path, want := range map[string]delegation.Delegation{"1": middle, "1.1": other, "1.2": root} {
+			d, err := ExtractProof(leaf, path)
+			require.NoError(t, err, path)
+			assert.Equal(t, want.Link(), d.Link(), path)
+		}
+	})
+
+	t.Run("CID", func(t *testing.T) {
+		d, err := ExtractProof(leaf, root.Link().String())
+		require.NoError(t, err)
+		assert.Equal(t, root.Link(), d.Link())
+	})
+
+	t.Run("OwnBlocks", func(t *testing.T) {
+		d, err := ExtractProof(leaf, "1")
+		require.NoError(t, err)
+		assert.Equal(t, blockCount(middle), blockCount(d))
+
+		// the archive of the proof holds its own chain and decodes on its own
+		encoded, err := FormatDelegation(d.Archive())
+		require.NoError(t, err)
+		decoded, err := DecodeDelegation(encoded)
+		require.NoError(t, err)
+		info := Inspect(decoded)
+		assert.Len(t, info.ProofDelegations, 2)
+		assert.Empty(t, ProofChainIssues(info))
+
+		d, err = ExtractProof(leaf, "1.2")
+		require.NoError(t, err)
+		assert.Equal(t, 1, blockCount(d))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for ref, msg := range map[string]string{
+			"2":                  "no proof 2 at depth 1",
+			"1.3":                "no proof 3 at depth 2",
+			"0":                  "no proof 0",
+			"1.1.1":              "no proof 1 at depth 3",
+			"not-a-cid":          "neither a CID nor an index path",
+			leaf.Link().String(): "is not in the chain",
+			"1..2":               "neither a CID nor an index path",
+		} {
+			_, err := ExtractProof(leaf, ref)
+			assert.ErrorContains(t, err, msg, ref)
+		}
+
+		// proofs referenced by CID but not included in the archive
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(root.Link())))
+		require.NoError(t, err)
+		_, err = ExtractProof(d, "1")
+		assert.ErrorContains(t, err, "not included in the archive or cannot be read: "+root.Link().String())
+		_, err = ExtractProof(d, root.Link().String())
+		assert.ErrorContains(t, err, "not included in the archive")
+	})
+
+	t.Run("MissingFirstProof", func(t *testing.T) {
+		// the first proof is referenced by CID only, so parse numbers the
+		// second proof 1
+		missing, err := New(service).To(node).Grant("blob/*", service.DID().String(), nil).WithNonce("missing").Build()
+		require.NoError(t, err)
+		d, err := MakeDelegation(node, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(missing.Link()), delegation.FromDelegation(middle)))
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		require.Len(t, info.ProofDelegations, 1)
+		assert.Equal(t, middle.Link().String(), info.ProofDelegations[0].CID)
+
+		for path, want := range map[string]delegation.Delegation{"1": middle, "1.2": root} {
+			p, err := ExtractProof(d, path)
+			require.NoError(t, err, path)
+			assert.Equal(t, want.Link(), p.Link(), path)
+		}
+		_, err = ExtractProof(d, "2")
+		assert.ErrorContains(t, err, "no proof 2 at depth 1")
+	})
+}
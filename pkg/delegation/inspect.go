@@ -0,0 +1,269 @@
+package delegation
+
+import (
+	"fmt"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+)
+
+// inspectConfig holds the options of Inspect
+type inspectConfig struct {
+	maxDepth int
+	caveats  bool
+	cids     bool
+	sources  []delegation.Delegation
+}
+
+// InspectOption configures Inspect
+type InspectOption func(*inspectConfig)
+
+// DefaultMaxDepth is the default maximum depth of the proof chain inspected
+const DefaultMaxDepth = 32
+
+// WithMaxDepth limits the depth of the proof chain that is inspected: 0
+// inspects the delegation only, 1 its proofs too, and so on, up to
+// DefaultMaxDepth by default. The proof chain is inspected in full when
+// depth is negative.
+func WithMaxDepth(depth int) InspectOption {
+	return func(c *inspectConfig) {
+		c.maxDepth = depth
+	}
+}
+
+// WithCaveats sets whether the caveats of capabilities are encoded, which is
+// the default
+func WithCaveats(include bool) InspectOption {
+	return func(c *inspectConfig) {
+		c.caveats = include
+	}
+}
+
+// WithCIDs sets whether the CIDs of the delegation and of its proofs are
+// computed, which is the default. ProofChainIssues cannot check a
+// DelegationInfo inspected without CIDs.
+func WithCIDs(compute bool) InspectOption {
+	return func(c *inspectConfig) {
+		c.cids = compute
+	}
+}
+
+// WithProofSources supplies delegations to resolve the proofs that are not
+// included in the archive of the inspected delegation from. Proofs resolved
+// from them are listed in ExternalProofs.
+func WithProofSources(sources ...delegation.Delegation) InspectOption {
+	return func(c *inspectConfig) {
+		c.sources = append(c.sources, sources...)
+	}
+}
+
+// Inspect returns the information about a delegation and, recursively, about
+// the proofs included in it. A proof shared by several delegations of the
+// chain is inspected once, under the least deep of them, and listed in
+// DuplicateProofs elsewhere. Proofs
+// that are neither included in the archive nor resolved from the proof
+// sources are listed in MissingProofs, and those that cannot be read in
+// ErroredProofs, with their errors in ProofErrors; the delegations whose chain
+// holds any are Incomplete.
+func Inspect(deleg delegation.Delegation, opts ...InspectOption) *DelegationInfo {
+	return inspect(deleg, nil, opts...)
+}
+
+// inspect is Inspect, calling visit, when not nil, for the delegation and
+// each proof delegation inspected in its chain
+func inspect(deleg delegation.Delegation, visit func(delegation.Delegation), opts ...InspectOption) *DelegationInfo {
+	cfg := inspectConfig{maxDepth: DefaultMaxDepth, caveats: true, cids: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	in := &inspector{cfg: cfg, visit: visit, seen: map[string]bool{deleg.Link().String(): true}}
+	var errs []string
+	// The archive of the delegation holds the blocks of the whole chain
+	archive, err := blockstore.NewBlockStore(blockstore.WithBlocksIterator(deleg.Blocks()))
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("reading archive blocks: %s", err))
+	} else {
+		in.archive, in.all = archive, archive
+	}
+	if in.archive != nil && len(cfg.sources) > 0 {
+		all, err := blockstore.NewBlockStore(blockstore.WithBlocksIterator(deleg.Blocks()))
+		if err == nil {
+			for _, src := range cfg.sources {
+				for b, berr := range src.Blocks() {
+					if berr == nil {
+						berr = all.Put(b)
+					}
+					if berr != nil {
+						err = berr
+						break
+					}
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("reading proof sources: %s", err))
+		} else {
+			in.all = all
+		}
+	}
+
+	info := in.inspect(deleg)
+	info.ProofErrors = append(info.ProofErrors, errs...)
+	info.Incomplete = info.Incomplete || len(errs) > 0
+	return info
+}
+
+// inspector inspects a delegation and the proofs in its chain
+type inspector struct {
+	cfg inspectConfig
+	// archive holds the blocks of the inspected delegation, and all those
+	// blocks and the blocks of the proof sources
+	archive blockstore.BlockReader
+	all     blockstore.BlockReader
+	// visit is called for each delegation inspected
+	visit func(delegation.Delegation)
+	// seen holds the CIDs of the delegations already inspected
+	seen map[string]bool
+}
+
+// pending is a delegation of the chain whose proofs are still to be inspected
+type pending struct {
+	deleg delegation.Delegation
+	info  *DelegationInfo
+	depth int
+}
+
+// inspect inspects the chain breadth first, so that a proof shared by several
+// delegations is inspected where it is the least deep, and is only listed as
+// a duplicate in deeper delegations that may be beyond the maximum depth
+func (in *inspector) inspect(deleg delegation.Delegation) *DelegationInfo {
+	root := in.describe(deleg)
+	queue := []pending{{deleg, root, 0}}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		queue = append(queue, in.expand(p)...)
+	}
+	markIncomplete(root)
+	return root
+}
+
+// describe returns the information about a delegation, without its proof
+// delegations
+func (in *inspector) describe(deleg delegation.Delegation) *DelegationInfo {
+	cfg := in.cfg
+	if in.visit != nil {
+		in.visit(deleg)
+	}
+	// Build result struct with detail
+	result := &DelegationInfo{
+		Issuer:     deleg.Issuer().DID().String(),
+		Audience:   deleg.Audience().DID().String(),
+		Version:    deleg.Version(),
+		Expiration: deleg.Expiration(),
+		NotBefore:  deleg.NotBefore(),
+		Nonce:      deleg.Nonce(),
+	}
+	if cfg.cids {
+		result.CID = deleg.Link().String()
+	}
+	// Delegations built for review with an Unsigned issuer have no signature
+	if len(deleg.Signature().Raw()) > 0 {
+		result.Signature = deleg.Signature().Bytes()
+	}
+
+	// Extract capabilities
+	for i, c := range deleg.Capabilities() {
+		capInfo := CapabilityInfo{
+			With: c.With(),
+			Can:  c.Can(),
+		}
+		if nb, ok := c.Nb().(datamodel.Node); ok && cfg.caveats {
+			if encoded, err := encodeDAGJSON(nb); err != nil {
+				result.EncodingErrors = append(result.EncodingErrors, fmt.Sprintf("encoding caveats of capability %d (%s): %s", i+1, c.Can(), err))
+			} else {
+				capInfo.Nb = encoded
+			}
+		}
+		result.Capabilities = append(result.Capabilities, capInfo)
+	}
+
+	// Extract facts, using the model to preserve the order of fact fields
+	for i, f := range deleg.Data().Model().Fct {
+		if encoded, err := encodeFact(f); err != nil {
+			result.EncodingErrors = append(result.EncodingErrors, fmt.Sprintf("encoding fact %d: %s", i+1, err))
+		} else {
+			result.Facts = append(result.Facts, encoded)
+		}
+	}
+
+	// Extract proof links
+	for _, p := range deleg.Proofs() {
+		if encoded, err := encodeDAGJSON(basicnode.NewLink(p)); err != nil {
+			result.EncodingErrors = append(result.EncodingErrors, fmt.Sprintf("encoding proof link %s: %s", p, err))
+		} else {
+			result.Proofs = append(result.Proofs, encoded)
+		}
+	}
+
+	return result
+}
+
+// expand inspects the proofs of a pending delegation, and returns the proof
+// delegations whose own proofs are to be inspected next
+func (in *inspector) expand(p pending) []pending {
+	deleg, result := p.deleg, p.info
+	if len(deleg.Proofs()) == 0 || in.all == nil {
+		return nil
+	}
+	if in.cfg.maxDepth >= 0 && p.depth >= in.cfg.maxDepth {
+		result.Truncated = true
+		return nil
+	}
+
+	var next []pending
+	for _, link := range deleg.Proofs() {
+		key := link.String()
+		if in.seen[key] {
+			result.DuplicateProofs = append(result.DuplicateProofs, key)
+			continue
+		}
+		_, ok, err := in.all.Get(link)
+		if err != nil {
+			result.ErroredProofs = append(result.ErroredProofs, key)
+			result.ProofErrors = append(result.ProofErrors, fmt.Sprintf("reading proof %s: %s", key, err))
+			continue
+		}
+		if !ok {
+			result.MissingProofs = append(result.MissingProofs, key)
+			continue
+		}
+		if _, inArchive, _ := in.archive.Get(link); !inArchive {
+			result.ExternalProofs = append(result.ExternalProofs, key)
+		}
+		pd, err := delegation.NewDelegationView(link, in.all)
+		if err != nil {
+			result.ErroredProofs = append(result.ErroredProofs, key)
+			result.ProofErrors = append(result.ProofErrors, fmt.Sprintf("decoding proof %s: %s", key, err))
+			continue
+		}
+		in.seen[key] = true
+		info := in.describe(pd)
+		result.ProofDelegations = append(result.ProofDelegations, info)
+		next = append(next, pending{pd, info, p.depth + 1})
+	}
+	return next
+}
+
+// markIncomplete marks the delegations whose chain holds missing proofs or
+// proofs that cannot be read as incomplete
+func markIncomplete(info *DelegationInfo) {
+	info.Incomplete = len(info.MissingProofs) > 0 || len(info.ProofErrors) > 0
+	for _, pd := range info.ProofDelegations {
+		markIncomplete(pd)
+		info.Incomplete = info.Incomplete || pd.Incomplete
+	}
+}
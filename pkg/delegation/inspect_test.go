@@ -0,0 +1,220 @@
+package delegation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/multiformats/go-multicodec"
+	"github.com/multiformats/go-multihash"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/ipld/block"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	node, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	root, err := New(service).To(operator).Grant("blob/*", service.DID().String(), nil).Build()
+	require.NoError(t, err)
+	middle, err := New(operator).
+		To(node).
+		Grant(capblob.AllocateAbility, service.DID().String(), spaceCaveats{"did:key:space"}).
+		WithProof(root).
+		Build()
+	require.NoError(t, err)
+	leaf, err := New(node).To(audience).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(middle).Build()
+	require.NoError(t, err)
+
+	depth := func(info *DelegationInfo) int {
+		n := 0
+		for len(info.ProofDelegations) > 0 {
+			info = info.ProofDelegations[0]
+			n++
+		}
+		return n
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		info := Inspect(leaf)
+		assert.Equal(t, leaf.Link().String(), info.CID)
+		assert.Equal(t, node.DID().String(), info.Issuer)
+		assert.Equal(t, 2, depth(info))
+		assert.Equal(t, middle.Link().String(), info.ProofDelegations[0].CID)
+		assert.JSONEq(t, `{"space":"did:key:space"}`, string(info.ProofDelegations[0].Capabilities[0].Nb))
+		assert.Empty(t, ProofChainIssues(info))
+
+		b64, err := FormatDelegation(leaf.Archive())
+		require.NoError(t, err)
+		parsed, err := ParseDelegationContent(b64)
+		require.NoError(t, err)
+		assert.Equal(t, info, parsed)
+	})
+
+	t.Run("MaxDepth", func(t *testing.T) {
+		for maxDepth, want := range map[int]int{0: 0, 1: 1, 2: 2, 5: 2, -1: 2} {
+			info := Inspect(leaf, WithMaxDepth(maxDepth))
+			assert.Equal(t, want, depth(info), "max depth %d", maxDepth)
+			// proof links are listed even when proofs are not inspected
+			assert.Len(t, info.Proofs, 1)
+		}
+
+		info := Inspect(leaf, WithMaxDepth(1))
+		assert.False(t, info.Truncated)
+		assert.True(t, info.ProofDelegations[0].Truncated)
+		// proofs beyond the maximum depth are not reported as missing
+		assert.Empty(t, ProofChainIssues(info))
+	})
+
+	t.Run("SharedProof", func(t *testing.T) {
+		first, err := New(operator).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(root).WithNonce("1").Build()
+		require.NoError(t, err)
+		second, err := New(operator).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(root).WithNonce("2").Build()
+		require.NoError(t, err)
+		d, err := New(node).To(audience).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(first, second).Build()
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		require.Len(t, info.ProofDelegations, 2)
+		require.Len(t, info.ProofDelegations[0].ProofDelegations, 1)
+		assert.Empty(t, info.ProofDelegations[1].ProofDelegations)
+		assert.Equal(t, []string{root.Link().String()}, info.ProofDelegations[1].DuplicateProofs)
+		assert.Empty(t, ProofChainIssues(info))
+
+		// the audience of a shared proof is checked for every delegation it supports
+		valid, err := New(node).To(audience).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(first).Build()
+		require.NoError(t, err)
+		invalid, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(first)))
+		require.NoError(t, err)
+		d, err = New(audience).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(valid, invalid).Build()
+		require.NoError(t, err)
+
+		info = Inspect(d)
+		assert.Equal(t, []string{first.Link().String()}, info.ProofDelegations[1].DuplicateProofs)
+		assert.Equal(t, []string{
+			"proof " + first.Link().String() + " is delegated to " + node.DID().String() + ", not to the issuer " + operator.DID().String() + " of " + invalid.Link().String(),
+		}, ProofChainIssues(info))
+	})
+
+	t.Run("SharedProofAtTwoDepths", func(t *testing.T) {
+		// middle is reached first through a chain that ends at the maximum
+		// depth, and then directly, where its own proof is within the maximum
+		// depth
+		deep := middle
+		for i := range DefaultMaxDepth - 1 {
+			deep, err = New(node).To(node).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(deep).WithNonce(fmt.Sprint(i)).Build()
+			require.NoError(t, err)
+		}
+		d, err := New(node).To(audience).Grant(capblob.AllocateAbility, service.DID().String(), nil).WithProof(deep, middle).Build()
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		require.Len(t, info.ProofDelegations, 2)
+		assert.Equal(t, DefaultMaxDepth-1, depth(info))
+		shared := info.ProofDelegations[1]
+		assert.Equal(t, middle.Link().String(), shared.CID)
+		assert.False(t, shared.Truncated)
+		require.Len(t, shared.ProofDelegations, 1)
+		assert.Equal(t, root.Link().String(), shared.ProofDelegations[0].CID)
+
+		// the chain through the shared proof is verified and searched
+		issues := VerifyDelegation(d, time.Now(), ResolveDIDKey)
+		assert.Empty(t, issues)
+		path, err := authorityPath(d, service.DID())
+		require.NoError(t, err)
+		assert.Equal(t, []ucan.Link{middle.Link(), root.Link()}, path)
+	})
+
+	t.Run("MissingProof", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(root.Link())))
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		assert.Empty(t, info.ProofDelegations)
+		assert.Equal(t, []string{root.Link().String()}, info.MissingProofs)
+		assert.Equal(t, []string{"proof " + root.Link().String() + " of " + d.Link().String() + " is not included"}, ProofChainIssues(info))
+		assert.True(t, info.Incomplete)
+		assert.Equal(t, []string{root.Link().String()}, UnresolvedProofs(info))
+	})
+
+	t.Run("ProofSources", func(t *testing.T) {
+		d, err := MakeDelegation(node, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(middle.Link())))
+		require.NoError(t, err)
+
+		// the archive of middle holds its own proof too
+		info := Inspect(d, WithProofSources(middle))
+		require.Len(t, info.ProofDelegations, 1)
+		assert.Equal(t, []string{middle.Link().String()}, info.ExternalProofs)
+		assert.Equal(t, []string{root.Link().String()}, info.ProofDelegations[0].ExternalProofs)
+		assert.Empty(t, info.MissingProofs)
+		assert.False(t, info.Incomplete)
+		assert.Empty(t, UnresolvedProofs(info))
+		assert.Empty(t, ProofChainIssues(info))
+
+		info = Inspect(d, WithProofSources(root))
+		assert.Empty(t, info.ProofDelegations)
+		assert.True(t, info.Incomplete)
+		assert.Equal(t, []string{middle.Link().String()}, UnresolvedProofs(info))
+	})
+
+	t.Run("UnreadableProof", func(t *testing.T) {
+		// a proof included in the archive that is not a UCAN
+		data := []byte{0x63, 'f', 'o', 'o'} // CBOR "foo"
+		c, err := cid.Prefix{Version: 1, Codec: uint64(multicodec.DagCbor), MhType: multihash.SHA2_256, MhLength: -1}.Sum(data)
+		require.NoError(t, err)
+		blk := block.NewBlock(cidlink.Link{Cid: c}, data)
+
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromLink(blk.Link())))
+		require.NoError(t, err)
+		blocks := []ipld.Block{blk}
+		for b, err := range d.Blocks() {
+			require.NoError(t, err)
+			blocks = append(blocks, b)
+		}
+		bs, err := blockstore.NewBlockStore(blockstore.WithBlocks(blocks))
+		require.NoError(t, err)
+		d, err = delegation.NewDelegationView(d.Link(), bs)
+		require.NoError(t, err)
+
+		info := Inspect(d)
+		assert.Empty(t, info.MissingProofs)
+		require.Len(t, info.ProofErrors, 1)
+		assert.Contains(t, info.ProofErrors[0], "decoding proof "+blk.Link().String())
+		assert.Equal(t, []string{blk.Link().String()}, info.ErroredProofs)
+
+		issues := ProofChainIssues(info)
+		require.Len(t, issues, 1)
+		assert.Contains(t, issues[0], "decoding proof")
+	})
+
+	t.Run("WithoutCaveats", func(t *testing.T) {
+		info := Inspect(middle, WithCaveats(false))
+		assert.Nil(t, info.Capabilities[0].Nb)
+		assert.Equal(t, capblob.AllocateAbility, info.Capabilities[0].Can)
+	})
+
+	t.Run("WithoutCIDs", func(t *testing.T) {
+		info := Inspect(leaf, WithCIDs(false))
+		assert.Empty(t, info.CID)
+		assert.Empty(t, info.ProofDelegations[0].CID)
+		assert.Equal(t, operator.DID().String(), info.ProofDelegations[0].Issuer)
+	})
+}
@@ -0,0 +1,170 @@
+package delegation
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/multiformats/go-varint"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/ipld"
+	"github.com/storacha/go-ucanto/core/ipld/block"
+	"github.com/storacha/go-ucanto/core/ipld/codec/cbor"
+	"github.com/storacha/go-ucanto/core/ipld/hash/sha256"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	udm "github.com/storacha/go-ucanto/ucan/datamodel/ucan"
+)
+
+// UnsignedIssuer returns an issuer with the provided DID that issues unsigned
+// Ed25519 delegations, so that a delegation can be built without access to
+// the issuer private key, to be signed offline or reviewed before signing.
+// Such delegations carry an empty signature and are not valid.
+func UnsignedIssuer(id did.DID) ucan.Signer {
+	return unsignedIssuer{id}
+}
+
+type unsignedIssuer struct {
+	id did.DID
+}
+
+func (i unsignedIssuer) DID() did.DID {
+	return i.id
+}
+
+func (i unsignedIssuer) Sign(msg []byte) signature.SignatureView {
+	return signature.NewSignatureView(signature.NewSignature(i.SignatureCode(), []byte{}))
+}
+
+func (i unsignedIssuer) SignatureCode() uint64 {
+	return signature.EdDSA
+}
+
+func (i unsignedIssuer) SignatureAlgorithm() string {
+	return "EdDSA"
+}
+
+// EncodePayload returns the DAG-CBOR encoded UCAN of an unsigned delegation,
+// the payload to carry to the issuer for signing. The payload holds the UCAN
+// alone, so a delegation with proofs is refused: Assemble could not attach
+// the proof blocks, and the chain of the delegation could not be checked.
+func EncodePayload(d delegation.Delegation) ([]byte, error) {
+	if len(d.Signature().Raw()) > 0 {
+		return nil, fmt.Errorf("delegation %s is already signed", d.Link())
+	}
+	if len(d.Proofs()) > 0 {
+		return nil, fmt.Errorf("delegation %s has proofs, which unsigned payloads cannot carry", d.Link())
+	}
+	return d.Root().Bytes(), nil
+}
+
+// DecodePayload decodes an unsigned UCAN payload produced by EncodePayload.
+// Payloads with proofs are refused, as EncodePayload does.
+func DecodePayload(payload []byte) (ucan.View, error) {
+	var model udm.UCANModel
+	if err := cbor.Decode(payload, &model, udm.Type()); err != nil {
+		return nil, fmt.Errorf("decoding UCAN payload: %w", err)
+	}
+	u, err := ucan.NewUCAN(&model)
+	if err != nil {
+		return nil, fmt.Errorf("decoding UCAN payload: %w", err)
+	}
+	if len(u.Signature().Raw()) > 0 {
+		return nil, fmt.Errorf("UCAN payload is already signed")
+	}
+	if len(u.Proofs()) > 0 {
+		return nil, fmt.Errorf("UCAN payload has proofs, which unsigned payloads cannot carry")
+	}
+	return u, nil
+}
+
+// SignPayload signs an unsigned UCAN payload with the issuer and returns the
+// encoded signature. The issuer must have the DID of the UCAN issuer. The
+// check of a CheckingIssuer is run on the payload before it is signed.
+func SignPayload(issuer ucan.Signer, payload []byte) ([]byte, error) {
+	u, err := DecodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if ci, ok := issuer.(*checkingIssuer); ok {
+		if err := ci.check(u); err != nil {
+			return nil, err
+		}
+		issuer = ci.Signer
+	}
+	if issuer.DID() != u.Issuer().DID() {
+		return nil, fmt.Errorf("issuer %s is not the issuer of the payload (%s)", issuer.DID(), u.Issuer().DID())
+	}
+	if issuer.SignatureCode() != u.Signature().Code() {
+		return nil, fmt.Errorf("issuer signs with %s but the payload expects a different algorithm", issuer.SignatureAlgorithm())
+	}
+
+	msg, err := signingPayload(u)
+	if err != nil {
+		return nil, err
+	}
+	sig := issuer.Sign(msg)
+	if err := checkSigned(issuer, sig); err != nil {
+		return nil, err
+	}
+	return sig.Bytes(), nil
+}
+
+// Assemble combines an unsigned UCAN payload with its encoded signature into
+// a delegation, after checking the signature with the verifier of the issuer
+// key.
+func Assemble(payload []byte, sig []byte, verifier signature.Verifier) (delegation.Delegation, error) {
+	u, err := DecodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignatureEncoding(sig); err != nil {
+		return nil, err
+	}
+
+	model := *u.Model()
+	model.S = sig
+	// An empty proofs list decodes as nil, which would be omitted when encoded
+	if model.Prf == nil {
+		model.Prf = []ipld.Link{}
+	}
+	signed, err := ucan.NewUCAN(&model)
+	if err != nil {
+		return nil, fmt.Errorf("decoding signed UCAN: %w", err)
+	}
+	ok, err := VerifySignature(signed, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("verifying signature: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: signature is not valid for the payload issued by %s", ErrInvalidSignature, u.Issuer().DID())
+	}
+
+	rt, err := block.Encode(&model, udm.Type(), cbor.Codec, sha256.Hasher)
+	if err != nil {
+		return nil, fmt.Errorf("encoding UCAN: %w", err)
+	}
+	bs, err := blockstore.NewBlockStore(blockstore.WithBlocks([]ipld.Block{rt}))
+	if err != nil {
+		return nil, fmt.Errorf("creating block store: %w", err)
+	}
+	return delegation.NewDelegation(rt, bs)
+}
+
+// checkSignatureEncoding checks that the bytes are a signature encoded as
+// the algorithm code, the length of the raw signature and the raw signature
+func checkSignatureEncoding(sig []byte) error {
+	r := bytes.NewReader(sig)
+	if _, err := varint.ReadUvarint(r); err != nil {
+		return fmt.Errorf("%w encoding: %w", ErrInvalidSignature, err)
+	}
+	size, err := varint.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("%w encoding: %w", ErrInvalidSignature, err)
+	}
+	if size == 0 || uint64(r.Len()) != size {
+		return fmt.Errorf("%w encoding: expected %d signature bytes, found %d", ErrInvalidSignature, size, r.Len())
+	}
+	return nil
+}
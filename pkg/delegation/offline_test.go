@@ -0,0 +1,140 @@
+package delegation
+
+import (
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOfflineSigning(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	unsigned, err := MakeDelegation(UnsignedIssuer(issuer.DID()), audience, []string{capblob.AllocateAbility},
+		delegation.WithNoExpiration(),
+		delegation.WithNonce("nonce"),
+		delegation.WithNotBefore(100),
+	)
+	require.NoError(t, err)
+
+	payload, err := EncodePayload(unsigned)
+	require.NoError(t, err)
+
+	t.Run("SignAndAssemble", func(t *testing.T) {
+		sig, err := SignPayload(issuer, payload)
+		require.NoError(t, err)
+
+		d, err := Assemble(payload, sig, issuer.Verifier())
+		require.NoError(t, err)
+
+		expected, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility},
+			delegation.WithNoExpiration(),
+			delegation.WithNonce("nonce"),
+			delegation.WithNotBefore(100),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, expected.Link(), d.Link())
+
+		b64, err := FormatDelegation(d.Archive())
+		require.NoError(t, err)
+		info, err := ParseDelegationContent(b64)
+		require.NoError(t, err)
+		assert.Equal(t, issuer.DID().String(), info.Issuer)
+	})
+
+	t.Run("WrongIssuer", func(t *testing.T) {
+		_, err := SignPayload(other, payload)
+		require.ErrorContains(t, err, "is not the issuer")
+
+		sig := other.Sign([]byte("message")).Bytes()
+		_, err = Assemble(payload, sig, issuer.Verifier())
+		require.ErrorContains(t, err, "signature is not valid")
+	})
+
+	t.Run("InvalidSignature", func(t *testing.T) {
+		_, err := Assemble(payload, []byte{0xed}, issuer.Verifier())
+		require.ErrorContains(t, err, "invalid signature encoding")
+	})
+
+	t.Run("AlreadySigned", func(t *testing.T) {
+		d, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		_, err = EncodePayload(d)
+		require.ErrorContains(t, err, "already signed")
+	})
+
+	t.Run("Proofs", func(t *testing.T) {
+		proof, err := MakeDelegation(other, issuer, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+		d, err := MakeDelegation(UnsignedIssuer(issuer.DID()), audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		_, err = EncodePayload(d)
+		require.ErrorContains(t, err, "has proofs")
+
+		// payloads encoded elsewhere are refused as well
+		_, err = SignPayload(issuer, d.Root().Bytes())
+		require.ErrorContains(t, err, "has proofs")
+		sig := issuer.Sign([]byte("message")).Bytes()
+		_, err = Assemble(d.Root().Bytes(), sig, issuer.Verifier())
+		require.ErrorContains(t, err, "has proofs")
+	})
+
+	t.Run("DidWeb", func(t *testing.T) {
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+
+		webIssuer, err := signer.Wrap(issuer, web)
+		require.NoError(t, err)
+
+		unsigned, err := MakeDelegation(UnsignedIssuer(web), audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		payload, err := EncodePayload(unsigned)
+		require.NoError(t, err)
+
+		sig, err := SignPayload(webIssuer, payload)
+		require.NoError(t, err)
+
+		d, err := Assemble(payload, sig, issuer.Verifier())
+		require.NoError(t, err)
+		assert.Equal(t, web, d.Issuer().DID())
+	})
+}
+
+func TestUnsignedIssuer(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	d, err := MakeDelegation(UnsignedIssuer(issuer.DID()), audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration(), delegation.WithNonce("nonce"))
+	require.NoError(t, err)
+	assert.Empty(t, d.Signature().Raw())
+
+	b64, err := FormatDelegation(d.Archive())
+	require.NoError(t, err)
+
+	info, err := ParseDelegationContent(b64)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.DID().String(), info.Issuer)
+	assert.Equal(t, "nonce", info.Nonce)
+	assert.Empty(t, info.Signature)
+
+	ok, err := VerifySignature(d.Data(), issuer.Verifier())
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,156 @@
+package delegation
+
+import (
+	"fmt"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/storacha/go-ucanto/core/dag/blockstore"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Reissue re-signs a delegation with a new issuer. The audience, capabilities,
+// facts, nonce and validity window are carried over unchanged, except for
+// capabilities on the resource of the old issuer, which are granted on the
+// resource of the new issuer instead. Options are applied last and so
+// override the carried over values.
+//
+// The proofs of the delegation are carried over when proofs is nil. They are
+// delegated to the old issuer, so when the DID of the issuer changes they
+// would not support the reissued delegation: proofs delegated to the new
+// issuer must then be provided in their place, or ErrProofsRequired is
+// returned.
+func Reissue(issuer ucan.Signer, d delegation.Delegation, proofs []delegation.Delegation, opts ...delegation.Option) (delegation.Delegation, error) {
+	oldIssuer := d.Issuer().DID().String()
+
+	var prfs []delegation.Proof
+	switch {
+	case proofs != nil:
+		for _, p := range proofs {
+			if p.Audience().DID() != issuer.DID() {
+				return nil, fmt.Errorf("proof %s is delegated to %s, not to the new issuer %s", p.Link(), p.Audience().DID(), issuer.DID())
+			}
+			prfs = append(prfs, delegation.FromDelegation(p))
+		}
+	case len(d.Proofs()) > 0 && issuer.DID().String() != oldIssuer:
+		return nil, fmt.Errorf("%w: delegation %s has proofs delegated to %s, not to the new issuer %s", ErrProofsRequired, d.Link(), oldIssuer, issuer.DID())
+	default:
+		var err error
+		if prfs, err = resolveProofLinks(d); err != nil {
+			return nil, err
+		}
+	}
+
+	var uc []ucan.Capability[nodeCaveats]
+	for _, c := range d.Capabilities() {
+		with := c.With()
+		if with == oldIssuer {
+			with = issuer.DID().String()
+		}
+		nb, _ := c.Nb().(datamodel.Node)
+		uc = append(uc, ucan.NewCapability(c.Can(), with, nodeCaveats{nb}))
+	}
+
+	options := []delegation.Option{
+		delegation.WithNotBefore(d.NotBefore()),
+		delegation.WithProof(prfs...),
+	}
+	if exp := d.Expiration(); exp != nil {
+		options = append(options, delegation.WithExpiration(*exp))
+	} else {
+		options = append(options, delegation.WithNoExpiration())
+	}
+	if nnc := d.Nonce(); nnc != "" {
+		options = append(options, delegation.WithNonce(nnc))
+	}
+	if fct := d.Data().Model().Fct; len(fct) > 0 {
+		facts := make([]ucan.FactBuilder, 0, len(fct))
+		for _, f := range fct {
+			facts = append(facts, nodeFact(f.Values))
+		}
+		options = append(options, delegation.WithFacts(facts))
+	}
+	options = append(options, opts...)
+
+	nd, err := delegate(issuer, d.Audience(), uc, options...)
+	if err != nil {
+		return nil, fmt.Errorf("reissuing delegation %s: %w", d.Link(), err)
+	}
+	return nd, nil
+}
+
+// SelectProofs returns the candidates that support the delegation once it is
+// reissued by the new issuer, to pass to Reissue in place of its proofs: the
+// candidates delegated to the new issuer that grant at least one of its
+// capabilities. ErrProofsRequired is returned when a capability is granted
+// by none of the candidates.
+func SelectProofs(issuer did.DID, d delegation.Delegation, candidates []delegation.Delegation) ([]delegation.Delegation, error) {
+	oldIssuer := d.Issuer().DID().String()
+	selected := []delegation.Delegation{}
+	covered := make([]bool, len(d.Capabilities()))
+	for _, p := range candidates {
+		if p.Audience().DID() != issuer {
+			continue
+		}
+		var grants bool
+		for i, c := range d.Capabilities() {
+			with := c.With()
+			if with == oldIssuer {
+				with = issuer.String()
+			}
+			for _, pc := range p.Capabilities() {
+				// A capability on the resource of the new issuer is supported
+				// by a proof of its ability on any resource
+				if AbilityCovers(pc.Can(), c.Can()) && (pc.With() == with || with == issuer.String()) {
+					covered[i], grants = true, true
+				}
+			}
+		}
+		if grants {
+			selected = append(selected, p)
+		}
+	}
+	for i, ok := range covered {
+		if !ok {
+			c := d.Capabilities()[i]
+			return nil, fmt.Errorf("%w: no proof delegated to %s grants %s on %s for delegation %s", ErrProofsRequired, issuer, c.Can(), c.With(), d.Link())
+		}
+	}
+	return selected, nil
+}
+
+// resolveProofLinks returns the proofs of a delegation, as full delegations
+// when they are included and as links otherwise
+func resolveProofLinks(d delegation.Delegation) ([]delegation.Proof, error) {
+	if len(d.Proofs()) == 0 {
+		return nil, nil
+	}
+	br, err := blockstore.NewBlockReader(blockstore.WithBlocksIterator(d.Blocks()))
+	if err != nil {
+		return nil, fmt.Errorf("reading blocks of delegation %s: %w", d.Link(), err)
+	}
+	included := map[string]delegation.Delegation{}
+	for _, proof := range delegation.NewProofsView(d.Proofs(), br) {
+		if pd, ok := proof.Delegation(); ok {
+			included[pd.Link().String()] = pd
+		}
+	}
+
+	proofs := make([]delegation.Proof, 0, len(d.Proofs()))
+	for _, link := range d.Proofs() {
+		if pd, ok := included[link.String()]; ok {
+			proofs = append(proofs, delegation.FromDelegation(pd))
+		} else {
+			proofs = append(proofs, delegation.FromLink(link))
+		}
+	}
+	return proofs, nil
+}
+
+// nodeFact carries a fact that is already in IPLD form.
+type nodeFact map[string]datamodel.Node
+
+func (f nodeFact) ToIPLD() (map[string]datamodel.Node, error) {
+	return f, nil
+}
@@ -0,0 +1,145 @@
+package delegation
+
+import (
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReissue(t *testing.T) {
+	oldKey, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	newKey, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	proof, err := MakeDelegation(audience, oldKey, []string{capclaim.CacheAbility})
+	require.NoError(t, err)
+	newProof, err := MakeDelegation(audience, newKey, []string{capclaim.CacheAbility})
+	require.NoError(t, err)
+
+	t.Run("PreservesFields", func(t *testing.T) {
+		exp := ucan.Now() + 3600
+		old, err := delegation.Delegate(oldKey, audience, []ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability(capblob.AllocateAbility, oldKey.DID().String(), ucan.NoCaveats{}),
+			ucan.NewCapability(capclaim.CacheAbility, audience.DID().String(), ucan.NoCaveats{}),
+		},
+			delegation.WithExpiration(exp),
+			delegation.WithNotBefore(100),
+			delegation.WithNonce("nonce"),
+			delegation.WithFacts([]ucan.FactBuilder{testFact{"name": basicnode.NewString("test")}}),
+			delegation.WithProof(delegation.FromDelegation(proof)),
+		)
+		require.NoError(t, err)
+
+		// the proof is delegated to the old issuer, and does not support the
+		// reissued delegation
+		_, err = Reissue(newKey, old, nil)
+		require.ErrorIs(t, err, ErrProofsRequired)
+		_, err = Reissue(newKey, old, []delegation.Delegation{proof})
+		require.ErrorContains(t, err, "not to the new issuer")
+
+		d, err := Reissue(newKey, old, []delegation.Delegation{newProof})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, old.Link(), d.Link())
+		assert.Equal(t, newKey.DID(), d.Issuer().DID())
+		assert.Equal(t, audience.DID(), d.Audience().DID())
+		require.NotNil(t, d.Expiration())
+		assert.Equal(t, exp, *d.Expiration())
+		assert.Equal(t, 100, d.NotBefore())
+		assert.Equal(t, "nonce", d.Nonce())
+
+		require.Len(t, d.Capabilities(), 2)
+		assert.Equal(t, capblob.AllocateAbility, d.Capabilities()[0].Can())
+		assert.Equal(t, newKey.DID().String(), d.Capabilities()[0].With())
+		assert.Equal(t, audience.DID().String(), d.Capabilities()[1].With())
+
+		oldInfo := Inspect(old)
+		info := Inspect(d)
+		assert.Equal(t, oldInfo.Facts, info.Facts)
+		require.Len(t, info.ProofDelegations, 1)
+		assert.Equal(t, newProof.Link().String(), info.ProofDelegations[0].CID)
+		assert.Empty(t, ProofChainIssues(info))
+	})
+
+	t.Run("SameIssuerKeepsProofs", func(t *testing.T) {
+		old, err := MakeDelegation(oldKey, audience, []string{capclaim.CacheAbility}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		d, err := Reissue(oldKey, old, nil, delegation.WithNonce("renewed"))
+		require.NoError(t, err)
+		info := Inspect(d)
+		assert.Equal(t, Inspect(old).Proofs, info.Proofs)
+		require.Len(t, info.ProofDelegations, 1)
+		assert.Equal(t, proof.Link().String(), info.ProofDelegations[0].CID)
+		assert.Empty(t, ProofChainIssues(info))
+	})
+
+	t.Run("SelectProofs", func(t *testing.T) {
+		old, err := delegation.Delegate(oldKey, audience, []ucan.Capability[ucan.NoCaveats]{
+			ucan.NewCapability(capclaim.CacheAbility, audience.DID().String(), ucan.NoCaveats{}),
+		}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		unrelated, err := MakeDelegation(audience, newKey, []string{capblob.AcceptAbility})
+		require.NoError(t, err)
+		toOther, err := MakeDelegation(audience, oldKey, []string{capclaim.CacheAbility})
+		require.NoError(t, err)
+
+		selected, err := SelectProofs(newKey.DID(), old, []delegation.Delegation{unrelated, toOther, newProof})
+		require.NoError(t, err)
+		require.Len(t, selected, 1)
+		assert.Equal(t, newProof.Link(), selected[0].Link())
+
+		d, err := Reissue(newKey, old, selected)
+		require.NoError(t, err)
+		assert.Empty(t, ProofChainIssues(Inspect(d)))
+
+		_, err = SelectProofs(newKey.DID(), old, []delegation.Delegation{unrelated, toOther})
+		require.ErrorIs(t, err, ErrProofsRequired)
+		_, err = SelectProofs(newKey.DID(), old, nil)
+		require.ErrorIs(t, err, ErrProofsRequired)
+	})
+
+	t.Run("NoExpiration", func(t *testing.T) {
+		old, err := MakeDelegation(oldKey, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+
+		d, err := Reissue(newKey, old, nil)
+		require.NoError(t, err)
+		assert.Nil(t, d.Expiration())
+	})
+
+	t.Run("DidWeb", func(t *testing.T) {
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+
+		oldIssuer, err := signer.Wrap(oldKey, web)
+		require.NoError(t, err)
+
+		newIssuer, err := signer.Wrap(newKey, web)
+		require.NoError(t, err)
+
+		old, err := MakeDelegation(oldIssuer, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		d, err := Reissue(newIssuer, old, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "did:web:example.com", d.Issuer().DID().String())
+		assert.Equal(t, "did:web:example.com", d.Capabilities()[0].With())
+		assert.NotEqual(t, old.Signature().Bytes(), d.Signature().Bytes())
+	})
+}
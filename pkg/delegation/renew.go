@@ -0,0 +1,37 @@
+package delegation
+
+import (
+	"fmt"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// Renew issues a delegation identical to the provided one, with the same
+// audience, capabilities, caveats, facts, nonce and proofs, but with new time
+// bounds: it has no not-before, so it is valid from now, and expires at the
+// provided expiration. The not-before of the delegation is dropped rather
+// than carried over, as ucan.VerifySignature and the go-ucanto validator leave
+// it out of the signed payload and would reject the renewed signature.
+// The issuer must have the DID of the delegation issuer, and its key must be
+// the one that signed the delegation, which is checked with the verifier of
+// the issuer: a principal.Signer, or any signer with a Verifier method such
+// as those of the keys package.
+func Renew(issuer ucan.Signer, d delegation.Delegation, expiration int) (delegation.Delegation, error) {
+	if issuer.DID() != d.Issuer().DID() {
+		return nil, fmt.Errorf("issuer %s is not the issuer of the delegation (%s)", issuer.DID(), d.Issuer().DID())
+	}
+	v, ok := verifierOf(issuer)
+	if !ok {
+		return nil, fmt.Errorf("issuer %s has no verifier to check the signature of the delegation", issuer.DID())
+	}
+	ok, err := VerifySignature(d.Data(), v)
+	if err != nil {
+		return nil, fmt.Errorf("verifying delegation signature: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: issuer key did not sign the delegation %s", ErrInvalidSignature, d.Link())
+	}
+
+	return Reissue(issuer, d, nil, delegation.WithNotBefore(0), delegation.WithExpiration(expiration))
+}
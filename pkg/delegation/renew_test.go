@@ -0,0 +1,103 @@
+package delegation
+
+import (
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenew(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	exp := ucan.Now() + 3600
+	old, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility},
+		delegation.WithExpiration(ucan.Now()+60),
+		delegation.WithNotBefore(100),
+		delegation.WithNonce("nonce"),
+	)
+	require.NoError(t, err)
+
+	t.Run("Renews", func(t *testing.T) {
+		d, err := Renew(issuer, old, exp)
+		require.NoError(t, err)
+
+		assert.Equal(t, issuer.DID(), d.Issuer().DID())
+		assert.Equal(t, audience.DID(), d.Audience().DID())
+		assert.Equal(t, old.Capabilities(), d.Capabilities())
+		assert.Equal(t, "nonce", d.Nonce())
+		assert.Zero(t, d.NotBefore())
+		assert.Equal(t, StatusValid, CheckExpiry(Inspect(d), time.Now(), 0))
+		require.NotNil(t, d.Expiration())
+		assert.Equal(t, exp, *d.Expiration())
+
+		ok, err := VerifySignature(d.Data(), issuer.Verifier())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("VerifiesWithGoUcanto", func(t *testing.T) {
+		// go-ucanto verifies signatures without the not-before, and without
+		// the nonce, so a renewed delegation must not gain a not-before
+		old, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility},
+			delegation.WithExpiration(ucan.Now()+60),
+			delegation.WithNotBefore(100),
+		)
+		require.NoError(t, err)
+
+		d, err := Renew(issuer, old, exp)
+		require.NoError(t, err)
+		ok, err := ucan.VerifySignature(d.Data(), issuer.Verifier())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("OtherIssuer", func(t *testing.T) {
+		_, err := Renew(other, old, exp)
+		require.ErrorContains(t, err, "is not the issuer")
+	})
+
+	t.Run("DidWeb", func(t *testing.T) {
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+
+		webIssuer, err := signer.Wrap(issuer, web)
+		require.NoError(t, err)
+
+		webOther, err := signer.Wrap(other, web)
+		require.NoError(t, err)
+
+		old, err := MakeDelegation(webIssuer, audience, []string{capblob.AllocateAbility}, delegation.WithNonce("nonce"))
+		require.NoError(t, err)
+
+		d, err := Renew(webIssuer, old, exp)
+		require.NoError(t, err)
+		assert.Equal(t, "did:web:example.com", d.Issuer().DID().String())
+
+		_, err = Renew(webOther, old, exp)
+		require.ErrorContains(t, err, "did not sign")
+	})
+
+	t.Run("NoVerifier", func(t *testing.T) {
+		old, err := MakeDelegation(issuer, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		// a signer that can sign but not verify
+		_, err = Renew(struct{ ucan.Signer }{issuer}, old, exp)
+		require.ErrorContains(t, err, "has no verifier")
+	})
+}
@@ -0,0 +1,116 @@
+package delegation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/fluent/qp"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/core/invocation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/ucan"
+)
+
+// RevokeAbility is the ability used to revoke a previously issued delegation.
+const RevokeAbility = "ucan/revoke"
+
+// RevokeCaveats are the caveats of a ucan/revoke invocation.
+type RevokeCaveats struct {
+	// UCAN is the link to the delegation being revoked.
+	UCAN ucan.Link
+	// Proof is the chain of delegations, starting from a proof of the revoked
+	// delegation, that establishes the authority of the revocation issuer. It is
+	// empty when the revocation issuer is the issuer of the revoked delegation.
+	Proof []ucan.Link
+}
+
+func (rc RevokeCaveats) ToIPLD() (datamodel.Node, error) {
+	return qp.BuildMap(basicnode.Prototype.Any, -1, func(ma datamodel.MapAssembler) {
+		qp.MapEntry(ma, "ucan", qp.Link(rc.UCAN))
+		if len(rc.Proof) > 0 {
+			qp.MapEntry(ma, "proof", qp.List(int64(len(rc.Proof)), func(la datamodel.ListAssembler) {
+				for _, p := range rc.Proof {
+					qp.ListEntry(la, qp.Link(p))
+				}
+			}))
+		}
+	})
+}
+
+// MakeRevocation creates a ucan/revoke invocation for the provided delegation
+// addressed to the audience (typically the service the revocation is submitted
+// to). The issuer must either be the issuer of the delegation or the issuer of
+// one of the delegations in its proof chain. The blocks of the revoked
+// delegation are attached to the invocation so the recipient can resolve it.
+func MakeRevocation(issuer ucan.Signer, audience ucan.Principal, target delegation.Delegation, opts ...delegation.Option) (invocation.IssuedInvocation, error) {
+	path, err := authorityPath(target, issuer.DID())
+	if err != nil {
+		return nil, err
+	}
+
+	capability := ucan.NewCapability(
+		RevokeAbility,
+		issuer.DID().String(),
+		RevokeCaveats{UCAN: target.Link(), Proof: path},
+	)
+
+	inv, err := invocation.Invoke(issuer, audience, capability, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating revocation invocation: %w", err)
+	}
+	if err := checkSigned(issuer, inv.Signature()); err != nil {
+		return nil, err
+	}
+
+	for b, err := range target.Export() {
+		if err != nil {
+			return nil, fmt.Errorf("exporting revoked delegation blocks: %w", err)
+		}
+		if err := inv.Attach(b); err != nil {
+			return nil, fmt.Errorf("attaching revoked delegation blocks: %w", err)
+		}
+	}
+
+	return inv, nil
+}
+
+// authorityPath finds, in the proof chain of the delegation walked as Inspect
+// walks it, a delegation issued by the provided DID. It returns the links of
+// the proofs traversed to reach it, which is empty when the delegation itself
+// was issued by the DID.
+func authorityPath(d delegation.Delegation, issuer did.DID) ([]ucan.Link, error) {
+	links := map[string]ucan.Link{}
+	info := inspect(d, func(pd delegation.Delegation) {
+		links[pd.Link().String()] = pd.Link()
+	}, WithCaveats(false))
+
+	if cids, ok := issuerPath(info, issuer.String()); ok {
+		path := make([]ucan.Link, 0, len(cids))
+		for _, cid := range cids {
+			path = append(path, links[cid])
+		}
+		return path, nil
+	}
+
+	err := fmt.Errorf("%s is not the issuer of delegation %s or of any delegation in its proof chain", issuer, d.Link())
+	if issues := ProofChainIssues(info); info.Incomplete && len(issues) > 0 {
+		err = fmt.Errorf("%w, whose proofs could not all be searched: %s", err, strings.Join(issues, "; "))
+	}
+	return nil, err
+}
+
+// issuerPath returns the CIDs of the proofs traversed from a delegation to a
+// delegation of its chain issued by the DID
+func issuerPath(info *DelegationInfo, issuer string) ([]string, bool) {
+	if info.Issuer == issuer {
+		return nil, true
+	}
+	for _, pd := range info.ProofDelegations {
+		if path, ok := issuerPath(pd, issuer); ok {
+			return append([]string{pd.CID}, path...), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,115 @@
+package delegation
+
+import (
+	"testing"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeRevocation(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	alice, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	bob, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	carol, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	root, err := MakeDelegation(alice, bob, []string{capblob.AllocateAbility})
+	require.NoError(t, err)
+
+	target, err := MakeDelegation(bob, carol, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(root)))
+	require.NoError(t, err)
+
+	t.Run("IssuerRevokes", func(t *testing.T) {
+		inv, err := MakeRevocation(bob, service, target)
+		require.NoError(t, err)
+
+		nb := revokeCaveats(t, inv)
+		assert.Equal(t, target.Link().String(), nb.ucan)
+		assert.Empty(t, nb.proof)
+		assert.Equal(t, bob.DID().String(), inv.Capabilities()[0].With())
+		assert.Equal(t, service.DID(), inv.Audience().DID())
+	})
+
+	t.Run("AuthorityInProofChainRevokes", func(t *testing.T) {
+		inv, err := MakeRevocation(alice, service, target)
+		require.NoError(t, err)
+
+		nb := revokeCaveats(t, inv)
+		assert.Equal(t, target.Link().String(), nb.ucan)
+		assert.Equal(t, []string{root.Link().String()}, nb.proof)
+	})
+
+	t.Run("RevokedDelegationIsAttached", func(t *testing.T) {
+		inv, err := MakeRevocation(bob, service, target)
+		require.NoError(t, err)
+
+		b64, err := FormatDelegation(inv.Archive())
+		require.NoError(t, err)
+
+		info, err := ParseDelegationContent(b64)
+		require.NoError(t, err)
+		assert.Equal(t, RevokeAbility, info.Capabilities[0].Can)
+
+		var found bool
+		for b, err := range inv.Export() {
+			require.NoError(t, err)
+			if b.Link().String() == target.Link().String() {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("UnauthorizedRevoker", func(t *testing.T) {
+		_, err := MakeRevocation(carol, service, target)
+		require.Error(t, err)
+	})
+}
+
+type decodedRevokeCaveats struct {
+	ucan  string
+	proof []string
+}
+
+func revokeCaveats(t *testing.T, inv delegation.Delegation) decodedRevokeCaveats {
+	t.Helper()
+
+	require.Len(t, inv.Capabilities(), 1)
+	capability := inv.Capabilities()[0]
+	require.Equal(t, RevokeAbility, capability.Can())
+
+	nb, ok := capability.Nb().(datamodel.Node)
+	require.True(t, ok)
+
+	var decoded decodedRevokeCaveats
+	un, err := nb.LookupByString("ucan")
+	require.NoError(t, err)
+	link, err := un.AsLink()
+	require.NoError(t, err)
+	decoded.ucan = link.String()
+
+	pn, err := nb.LookupByString("proof")
+	if err != nil {
+		return decoded
+	}
+	it := pn.ListIterator()
+	for !it.Done() {
+		_, v, err := it.Next()
+		require.NoError(t, err)
+		l, err := v.AsLink()
+		require.NoError(t, err)
+		decoded.proof = append(decoded.proof, l.String())
+	}
+	return decoded
+}
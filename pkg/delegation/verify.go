@@ -0,0 +1,115 @@
+package delegation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	pdm "github.com/storacha/go-ucanto/ucan/datamodel/payload"
+	"github.com/storacha/go-ucanto/ucan/formatter"
+)
+
+// VerifySignature reports whether the signature of a UCAN was made by the
+// provided verifier's key. Unlike ucan.VerifySignature, the nonce and
+// not-before fields are included in the signed payload, as they are when the
+// UCAN is issued, and the DID of the verifier is not compared to the issuer,
+// so that the key behind a did:web issuer can be checked.
+func VerifySignature(u ucan.View, verifier signature.Verifier) (bool, error) {
+	msg, err := signingPayload(u)
+	if err != nil {
+		return false, err
+	}
+	return verifier.Verify(msg, u.Signature()), nil
+}
+
+// signingPayload returns the bytes signed by the issuer of a UCAN, for the
+// signature algorithm of its signature
+func signingPayload(u ucan.View) ([]byte, error) {
+	alg, err := signature.CodeName(u.Signature().Code())
+	if err != nil {
+		return nil, fmt.Errorf("reading signature algorithm: %w", err)
+	}
+
+	var prfstrs []string
+	for _, link := range u.Proofs() {
+		prfstrs = append(prfstrs, link.String())
+	}
+
+	model := u.Model()
+	payload := pdm.PayloadModel{
+		Iss: u.Issuer().DID().String(),
+		Aud: u.Audience().DID().String(),
+		Att: model.Att,
+		Prf: prfstrs,
+		Exp: u.Expiration(),
+		Fct: model.Fct,
+		Nnc: model.Nnc,
+		Nbf: model.Nbf,
+	}
+
+	msg, err := formatter.FormatSignPayload(payload, u.Version(), alg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding signature payload: %w", err)
+	}
+	return []byte(msg), nil
+}
+
+// VerifierResolver returns a verifier for the key behind a DID
+type VerifierResolver func(id did.DID) (signature.Verifier, error)
+
+// ResolveDIDKey returns a verifier for an Ed25519 did:key
+func ResolveDIDKey(id did.DID) (signature.Verifier, error) {
+	if !strings.HasPrefix(id.String(), "did:key:") {
+		return nil, fmt.Errorf("cannot resolve %s: only did:key principals can be resolved", id)
+	}
+	v, err := verifier.Parse(id.String())
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", id, err)
+	}
+	return v, nil
+}
+
+// VerifyDelegation checks the signatures and time bounds of a delegation and
+// of each proof delegation in its chain, as well as the proof chain itself,
+// and returns the problems found: a SignatureError for each signature that
+// is not valid or cannot be verified, a TimeBoundsError for each delegation
+// used outside of its time bounds, and errors matching ErrInvalidProofChain
+// for the issues of the proof chain. Signatures are checked against the keys
+// returned by the resolver. The chain is walked as Inspect walks it: each
+// proof is checked once, however many delegations share it, proofs that
+// cannot be read are reported, and a chain deeper than DefaultMaxDepth is
+// reported rather than verified beyond that depth.
+func VerifyDelegation(d delegation.Delegation, now time.Time, resolve VerifierResolver) []error {
+	var issues []error
+	info := inspect(d, func(d delegation.Delegation) {
+		v, err := resolve(d.Issuer().DID())
+		if err == nil {
+			var ok bool
+			if ok, err = VerifySignature(d.Data(), v); ok {
+				return
+			}
+		}
+		issues = append(issues, &SignatureError{CID: d.Link().String(), Issuer: d.Issuer().DID().String(), Err: err})
+	}, WithCaveats(false))
+
+	Walk(info, func(info *DelegationInfo) {
+		switch status := CheckExpiry(info, now, 0); status {
+		case StatusExpired:
+			issues = append(issues, &TimeBoundsError{CID: info.CID, Status: status, At: time.Unix(int64(*info.Expiration), 0)})
+		case StatusNotYetValid:
+			issues = append(issues, &TimeBoundsError{CID: info.CID, Status: status, At: time.Unix(int64(info.NotBefore), 0)})
+		}
+		if info.Truncated {
+			issues = append(issues, fmt.Errorf("%w: proofs of %s are deeper than %d delegations and were not verified", ErrInvalidProofChain, info.CID, DefaultMaxDepth))
+		}
+	})
+	for _, issue := range ProofChainIssues(info) {
+		issues = append(issues, fmt.Errorf("%w: %s", ErrInvalidProofChain, issue))
+	}
+	return issues
+}
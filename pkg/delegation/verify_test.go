@@ -0,0 +1,112 @@
+package delegation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDelegation(t *testing.T) {
+	service, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	operator, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	t.Run("Valid", func(t *testing.T) {
+		proof, err := MakeDelegation(service, operator, []string{capblob.AllocateAbility}, delegation.WithNonce("nonce"), delegation.WithNotBefore(100))
+		require.NoError(t, err)
+
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithProof(delegation.FromDelegation(proof)))
+		require.NoError(t, err)
+
+		assert.Empty(t, VerifyDelegation(d, now, ResolveDIDKey))
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithExpiration(ucan.Now()-10))
+		require.NoError(t, err)
+
+		issues := VerifyDelegation(d, now, ResolveDIDKey)
+		require.Len(t, issues, 1)
+		assert.ErrorIs(t, issues[0], ErrExpired)
+		assert.NotErrorIs(t, issues[0], ErrNotYetValid)
+		assert.Contains(t, issues[0].Error(), "expired")
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		issues := VerifyDelegation(d, now, func(did.DID) (signature.Verifier, error) {
+			return service.Verifier(), nil
+		})
+		require.Len(t, issues, 1)
+		assert.ErrorIs(t, issues[0], ErrInvalidSignature)
+		var sigErr *SignatureError
+		require.ErrorAs(t, issues[0], &sigErr)
+		assert.Equal(t, d.Link().String(), sigErr.CID)
+		assert.Contains(t, issues[0].Error(), "is not valid for issuer")
+	})
+
+	t.Run("UnresolvableIssuer", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+
+		errUnresolvable := errors.New("unresolvable")
+		issues := VerifyDelegation(d, now, func(did.DID) (signature.Verifier, error) {
+			return nil, errUnresolvable
+		})
+		require.Len(t, issues, 1)
+		assert.ErrorIs(t, issues[0], ErrInvalidSignature)
+		assert.ErrorIs(t, issues[0], errUnresolvable)
+		assert.Contains(t, issues[0].Error(), "cannot be verified")
+	})
+
+	t.Run("NotYetValid", func(t *testing.T) {
+		d, err := MakeDelegation(operator, audience, []string{capblob.AllocateAbility}, delegation.WithNotBefore(ucan.Now()+3600))
+		require.NoError(t, err)
+
+		issues := VerifyDelegation(d, now, ResolveDIDKey)
+		require.Len(t, issues, 1)
+		assert.ErrorIs(t, issues[0], ErrNotYetValid)
+	})
+
+	t.Run("SharedProofsVerifiedOnce", func(t *testing.T) {
+		// each level has two proofs that share the level below, so walking
+		// every path would verify 2^levels delegations
+		const levels = 20
+		d, err := MakeDelegation(service, operator, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+		for i := range levels {
+			left, err := MakeDelegation(operator, operator, []string{capblob.AllocateAbility}, delegation.WithNonce(fmt.Sprintf("left-%d", i)), delegation.WithProof(delegation.FromDelegation(d)))
+			require.NoError(t, err)
+			right, err := MakeDelegation(operator, operator, []string{capblob.AllocateAbility}, delegation.WithNonce(fmt.Sprintf("right-%d", i)), delegation.WithProof(delegation.FromDelegation(d)))
+			require.NoError(t, err)
+			d, err = MakeDelegation(operator, operator, []string{capblob.AllocateAbility}, delegation.WithNonce(fmt.Sprintf("top-%d", i)), delegation.WithProof(delegation.FromDelegation(left), delegation.FromDelegation(right)))
+			require.NoError(t, err)
+		}
+
+		verified := 0
+		VerifyDelegation(d, now, func(id did.DID) (signature.Verifier, error) {
+			verified++
+			return ResolveDIDKey(id)
+		})
+		assert.LessOrEqual(t, verified, 3*DefaultMaxDepth+1)
+	})
+}
@@ -0,0 +1,243 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+)
+
+// The agent protocol is a small HTTP API served by a process holding a key:
+//
+//	GET  /identity  -> {"did": "did:key:...", "algorithm": "EdDSA"}
+//	POST /sign      {"payload": "<base64>"} -> {"signature": "<base64 raw signature>"}
+//
+// Requests carry the agent token as a bearer token, which is only optional on
+// a unix socket. Errors are reported with a non-2xx status and a
+// {"error": "..."} body.
+
+// AgentTokenEnv is the environment variable holding the token sent to agents
+// opened by Open
+const AgentTokenEnv = "MKDELEGATION_AGENT_TOKEN"
+
+// agentProbe is signed when an agent is opened, to fail before issuing
+// anything when the agent cannot sign
+var agentProbe = []byte("mkdelegation agent probe")
+
+// AgentIdentity is the response of the identity endpoint of an agent
+type AgentIdentity struct {
+	DID       string `json:"did"`
+	Algorithm string `json:"algorithm"`
+}
+
+// AgentSignRequest is the body of a request to the sign endpoint of an agent
+type AgentSignRequest struct {
+	Payload []byte `json:"payload"`
+}
+
+// AgentSignResponse is the response of the sign endpoint of an agent
+type AgentSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// UnixSocketClient returns an HTTP client that connects to the unix socket at
+// the provided path
+func UnixSocketClient(path string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+		Timeout: 30 * time.Second,
+	}
+}
+
+// agentSigner signs with an Ed25519 key held by an agent
+type agentSigner struct {
+	client   *http.Client
+	baseURL  string
+	token    string
+	verifier principal.Verifier
+}
+
+// NewAgentSigner returns a signer for the Ed25519 key held by the agent at
+// the base URL, using the default HTTP client when client is nil and sending
+// the token, when not empty. The agent is asked for a signature, so that an
+// agent that cannot sign is reported here rather than when issuing.
+func NewAgentSigner(client *http.Client, baseURL string, token string) (ucan.Signer, error) {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	s := &agentSigner{client: client, baseURL: baseURL, token: token}
+
+	req, err := s.request(http.MethodGet, "/identity", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting agent identity: %w", err)
+	}
+	defer res.Body.Close()
+	var identity AgentIdentity
+	if err := decodeAgentResponse(res, &identity); err != nil {
+		return nil, fmt.Errorf("requesting agent identity: %w", err)
+	}
+
+	if identity.Algorithm != ed25519.SignatureAlgorithm {
+		return nil, fmt.Errorf("agent signs with %s, only %s is supported", identity.Algorithm, ed25519.SignatureAlgorithm)
+	}
+	s.verifier, err = verifier.Parse(identity.DID)
+	if err != nil {
+		return nil, fmt.Errorf("parsing agent DID %s: %w", identity.DID, err)
+	}
+
+	if _, err := s.sign(agentProbe); err != nil {
+		return nil, fmt.Errorf("probing agent: %w", err)
+	}
+	return s, nil
+}
+
+func (s *agentSigner) DID() did.DID {
+	return s.verifier.DID()
+}
+
+func (s *agentSigner) SignatureCode() uint64 {
+	return ed25519.SignatureCode
+}
+
+func (s *agentSigner) SignatureAlgorithm() string {
+	return ed25519.SignatureAlgorithm
+}
+
+// Close closes the idle connections to the agent
+func (s *agentSigner) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// Verifier returns the verifier of the agent key
+func (s *agentSigner) Verifier() principal.Verifier {
+	return s.verifier
+}
+
+// Sign requests a signature from the agent. The signature is empty when the
+// agent fails to produce a valid one, which the functions of the delegation
+// package refuse with ErrSigningFailed.
+func (s *agentSigner) Sign(msg []byte) signature.SignatureView {
+	raw, err := s.sign(msg)
+	if err != nil {
+		return signature.NewSignatureView(signature.NewSignature(ed25519.SignatureCode, nil))
+	}
+	return signature.NewSignatureView(signature.NewSignature(ed25519.SignatureCode, raw))
+}
+
+func (s *agentSigner) sign(msg []byte) ([]byte, error) {
+	body, err := json.Marshal(AgentSignRequest{Payload: msg})
+	if err != nil {
+		return nil, err
+	}
+	req, err := s.request(http.MethodPost, "/sign", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting agent signature: %w", err)
+	}
+	defer res.Body.Close()
+	var out AgentSignResponse
+	if err := decodeAgentResponse(res, &out); err != nil {
+		return nil, fmt.Errorf("requesting agent signature: %w", err)
+	}
+	if !s.verifier.Verify(msg, signature.NewSignature(ed25519.SignatureCode, out.Signature)) {
+		return nil, fmt.Errorf("agent returned a signature that does not verify with the key of %s", s.DID())
+	}
+	return out.Signature, nil
+}
+
+func (s *agentSigner) request(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, s.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating agent request: %w", err)
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	return req, nil
+}
+
+func decodeAgentResponse(res *http.Response, v any) error {
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		var body struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err == nil && body.Error != "" {
+			return fmt.Errorf("agent responded %s: %s", res.Status, body.Error)
+		}
+		return fmt.Errorf("agent responded %s", res.Status)
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding agent response: %w", err)
+	}
+	return nil
+}
+
+// NewAgentHandler returns an HTTP handler serving the agent protocol for a
+// signer, to hold a key in a process separate from the one issuing
+// delegations. Requests must carry the token as a bearer token. Without a
+// token, requests are only served on a unix socket, whose file permissions
+// restrict who may sign.
+func NewAgentHandler(s ucan.Signer, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /identity", func(w http.ResponseWriter, r *http.Request) {
+		writeAgentJSON(w, http.StatusOK, AgentIdentity{DID: s.DID().String(), Algorithm: s.SignatureAlgorithm()})
+	})
+	mux.HandleFunc("POST /sign", func(w http.ResponseWriter, r *http.Request) {
+		var req AgentSignRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			writeAgentJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("decoding request: %s", err)})
+			return
+		}
+		sig := s.Sign(req.Payload).Raw()
+		if len(sig) == 0 {
+			writeAgentJSON(w, http.StatusInternalServerError, map[string]string{"error": "signing failed"})
+			return
+		}
+		writeAgentJSON(w, http.StatusOK, AgentSignResponse{Signature: sig})
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			if addr, _ := r.Context().Value(http.LocalAddrContextKey).(net.Addr); addr == nil || addr.Network() != "unix" {
+				writeAgentJSON(w, http.StatusForbidden, map[string]string{"error": "agent without a token only serves unix sockets"})
+				return
+			}
+		} else if presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			writeAgentJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid bearer token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func writeAgentJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,139 @@
+package keys
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// badSigner signs with another key than the one of its DID
+type badSigner struct {
+	ucan.Signer
+	other ucan.Signer
+}
+
+func (s badSigner) Sign(msg []byte) signature.SignatureView {
+	return s.other.Sign(msg)
+}
+
+func TestAgent(t *testing.T) {
+	key, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	t.Run("UnixSocket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "signer.sock")
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		srv := &http.Server{Handler: NewAgentHandler(key, "")}
+		go srv.Serve(listener)
+		defer srv.Close()
+
+		s, err := Open("agent://" + socket)
+		require.NoError(t, err)
+		assert.Equal(t, key.DID(), s.DID())
+
+		d, err := mkd.MakeDelegation(s, audience, []string{capblob.AllocateAbility}, delegation.WithNonce("nonce"))
+		require.NoError(t, err)
+
+		ok, err := mkd.VerifySignature(d.Data(), key.Verifier())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		srv := httptest.NewServer(NewAgentHandler(key, "s3cret"))
+		defer srv.Close()
+
+		s, err := NewAgentSigner(srv.Client(), srv.URL, "s3cret")
+		require.NoError(t, err)
+
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+		ws, err := Wrap(s, web)
+		require.NoError(t, err)
+
+		d, err := mkd.MakeDelegation(ws, audience, []string{capblob.AllocateAbility})
+		require.NoError(t, err)
+		assert.Equal(t, web, d.Issuer().DID())
+
+		ok, err := mkd.VerifySignature(d.Data(), key.Verifier())
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		srv := httptest.NewServer(NewAgentHandler(key, "s3cret"))
+		defer srv.Close()
+		_, err := NewAgentSigner(srv.Client(), srv.URL, "wrong")
+		require.ErrorContains(t, err, "missing or invalid bearer token")
+		_, err = NewAgentSigner(srv.Client(), srv.URL, "")
+		require.ErrorContains(t, err, "missing or invalid bearer token")
+
+		// without a token, the agent refuses to serve over TCP
+		open := httptest.NewServer(NewAgentHandler(key, ""))
+		defer open.Close()
+		_, err = NewAgentSigner(open.Client(), open.URL, "")
+		require.ErrorContains(t, err, "only serves unix sockets")
+	})
+
+	t.Run("KeyURI", func(t *testing.T) {
+		t.Setenv(AgentTokenEnv, "s3cret")
+
+		// plain HTTP is only used on loopback addresses
+		srv := httptest.NewServer(NewAgentHandler(key, "s3cret"))
+		defer srv.Close()
+		s, err := Open("agent://" + srv.Listener.Addr().String())
+		require.NoError(t, err)
+		assert.Equal(t, key.DID(), s.DID())
+
+		_, err = Open("agent://signer.example.com:8080")
+		require.ErrorContains(t, err, "use agent+https://signer.example.com:8080")
+
+		// agent+https reaches the agent over TLS, here with an untrusted certificate
+		tlsSrv := httptest.NewTLSServer(NewAgentHandler(key, "s3cret"))
+		defer tlsSrv.Close()
+		_, err = Open("agent+https://" + tlsSrv.Listener.Addr().String())
+		require.ErrorContains(t, err, "certificate")
+
+		_, err = Open("agent+https:///run/signer.sock")
+		require.ErrorContains(t, err, "requires a host")
+	})
+
+	t.Run("ProbeFailure", func(t *testing.T) {
+		srv := httptest.NewServer(NewAgentHandler(badSigner{key, other}, "s3cret"))
+		defer srv.Close()
+		_, err := NewAgentSigner(srv.Client(), srv.URL, "s3cret")
+		require.ErrorContains(t, err, "does not verify with the key of "+key.DID().String())
+	})
+
+	t.Run("SignFailure", func(t *testing.T) {
+		srv := httptest.NewServer(NewAgentHandler(key, "s3cret"))
+		s, err := NewAgentSigner(srv.Client(), srv.URL, "s3cret")
+		require.NoError(t, err)
+		srv.Close()
+
+		sig := s.Sign([]byte("message"))
+		assert.Empty(t, sig.Raw())
+		_, err = mkd.MakeDelegation(s, audience, []string{capblob.AllocateAbility})
+		require.ErrorIs(t, err, mkd.ErrSigningFailed)
+	})
+}
@@ -0,0 +1,96 @@
+package keys
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"encoding/hex"
+	"encoding/pem"
+	"testing"
+
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// RFC 8032 test 1 key, also used by the RFC 8037 JWK example
+const (
+	testSeedHex    = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	testSeedBase64 = "nWGxne/9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A="
+	testJWK        = `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+)
+
+func TestFormats(t *testing.T) {
+	seed, err := hex.DecodeString(testSeedHex)
+	require.NoError(t, err)
+	key, err := ed25519.FromRaw(crypto_ed25519.NewKeyFromSeed(seed))
+	require.NoError(t, err)
+
+	t.Run("Vectors", func(t *testing.T) {
+		for format, data := range map[Format]string{
+			FormatSeedHex:    testSeedHex,
+			FormatSeedBase64: testSeedBase64,
+			FormatJWK:        testJWK,
+		} {
+			t.Run(string(format), func(t *testing.T) {
+				assert.Equal(t, format, detectFormat([]byte(data)))
+
+				s, err := Parse([]byte(data))
+				require.NoError(t, err)
+				assert.Equal(t, key.DID(), s.DID())
+			})
+		}
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		for _, format := range Formats {
+			t.Run(string(format), func(t *testing.T) {
+				data, err := Encode(key, format)
+				require.NoError(t, err)
+				assert.Equal(t, format, detectFormat(data))
+
+				for _, from := range []Format{FormatAuto, format} {
+					s, err := Decode(data, from)
+					require.NoError(t, err)
+					assert.Equal(t, key.DID(), s.DID())
+					assert.Equal(t, key.Raw(), s.Raw())
+				}
+
+				// every format converts to every other format of the same key
+				for _, to := range Formats {
+					s, err := Parse(data)
+					require.NoError(t, err)
+					out, err := Encode(s, to)
+					require.NoError(t, err)
+					back, err := Parse(out)
+					require.NoError(t, err)
+					assert.Equal(t, key.DID(), back.DID())
+				}
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Parse([]byte("not a key"))
+		require.ErrorContains(t, err, "unrecognized private key format")
+
+		_, err = Decode([]byte(testSeedHex), "der")
+		require.ErrorContains(t, err, "unsupported private key format")
+
+		_, err = Decode([]byte("abcd"), FormatSeedHex)
+		require.ErrorContains(t, err, "seed is 32 bytes")
+
+		_, err = Parse([]byte(`{"kty":"OKP","crv":"Ed25519","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		require.ErrorContains(t, err, "is a public key")
+
+		_, err = Parse([]byte(`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"AAAA"}`))
+		require.ErrorContains(t, err, "does not match")
+
+		_, err = Parse([]byte(`{"kty":"EC","crv":"P-256","d":"AAAA"}`))
+		require.ErrorContains(t, err, "not an Ed25519 key")
+
+		block, err := ssh.MarshalPrivateKeyWithPassphrase(crypto_ed25519.PrivateKey(key.Raw()), "", []byte("passphrase"))
+		require.NoError(t, err)
+		_, err = Parse(pem.EncodeToMemory(block))
+		require.ErrorContains(t, err, "encrypted OpenSSH private keys are not supported")
+	})
+}
@@ -0,0 +1,207 @@
+package keys
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/signer"
+	"github.com/storacha/go-ucanto/principal/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+)
+
+// Open returns the signer identified by a key URI:
+//
+//   - file:///path/to/key.pem, or a plain path: an Ed25519 private key file in any of the Formats
+//   - env://NAME: an environment variable holding an Ed25519 private key in any of the Formats
+//   - multibase:M...: a multibase encoded Ed25519 private key, as formatted by ed25519.Format
+//   - agent:///path/to/signer.sock, agent+https://host:port or agent://host:port: a remote signer
+//     speaking the agent protocol over a unix socket, HTTPS or, for loopback hosts only, plain HTTP,
+//     which holds the key outside of this process. The token of the agent, required over TCP, is
+//     read from $MKDELEGATION_AGENT_TOKEN
+//   - ssh-agent:SHA256:...: an Ed25519 key held by the ssh-agent on $SSH_AUTH_SOCK, selected by
+//     fingerprint, or ssh-agent: for its only Ed25519 key
+func Open(uri string) (ucan.Signer, error) {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	// Paths, including Windows paths with a drive letter, have no scheme
+	if !ok || len(scheme) == 1 || strings.ContainsAny(scheme, "/\\.") {
+		return openFile(uri)
+	}
+
+	switch scheme {
+	case "file":
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key URI: %w", err)
+		}
+		return openFile(u.Path)
+	case "env":
+		name := strings.TrimPrefix(rest, "//")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+		return Parse([]byte(value))
+	case "multibase":
+		return Decode([]byte(rest), FormatMultibase)
+	case "agent", "agent+https":
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key URI: %w", err)
+		}
+		token := os.Getenv(AgentTokenEnv)
+		switch {
+		case scheme == "agent+https" && u.Host == "":
+			return nil, fmt.Errorf("agent+https key URI requires a host")
+		case scheme == "agent+https":
+			return NewAgentSigner(nil, "https://"+u.Host, token)
+		case u.Host == "":
+			return NewAgentSigner(UnixSocketClient(u.Path), "http://agent", token)
+		case !isLoopback(u.Hostname()):
+			// The token and the payloads to sign would cross the network in cleartext
+			return nil, fmt.Errorf("agent %s is not on a loopback address, use agent+https://%s to reach it over TLS", u.Host, u.Host)
+		default:
+			return NewAgentSigner(nil, "http://"+u.Host, token)
+		}
+	case "ssh-agent":
+		s, err := OpenSSHAgent(rest)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported key URI scheme %q", scheme)
+	}
+}
+
+// isLoopback reports whether a host name is localhost or a loopback address
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Close releases the connection held by a signer to its key, such as the
+// connection of a signer opened by OpenSSHAgent or of a signer wrapping it.
+// It does nothing for other signers.
+func Close(s ucan.Signer) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Wrap returns a signer that signs with the key of the provided signer under
+// a different DID, e.g. a did:web. The signer must be a principal.Signer or a
+// signer returned by this package.
+func Wrap(s ucan.Signer, id did.DID) (ucan.Signer, error) {
+	if !strings.HasPrefix(s.DID().String(), "did:key:") {
+		return nil, fmt.Errorf("signer %s is not a did:key", s.DID())
+	}
+	if ps, ok := s.(principal.Signer); ok {
+		return signer.Wrap(ps, id)
+	}
+	ks, ok := s.(interface{ Verifier() principal.Verifier })
+	if !ok {
+		return nil, fmt.Errorf("signer %s has no verifier", s.DID())
+	}
+	vrf, err := verifier.Wrap(ks.Verifier(), id)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedSigner{s, vrf}, nil
+}
+
+// wrappedSigner signs with the key of a signer that is not a principal.Signer
+// under a different DID
+type wrappedSigner struct {
+	key      ucan.Signer
+	verifier principal.Verifier
+}
+
+func (w wrappedSigner) DID() did.DID {
+	return w.verifier.DID()
+}
+
+func (w wrappedSigner) Close() error {
+	return Close(w.key)
+}
+
+// Verifier returns the verifier of the key under the DID of the signer
+func (w wrappedSigner) Verifier() principal.Verifier {
+	return w.verifier
+}
+
+func (w wrappedSigner) Sign(msg []byte) signature.SignatureView {
+	return w.key.Sign(msg)
+}
+
+func (w wrappedSigner) SignatureCode() uint64 {
+	return w.key.SignatureCode()
+}
+
+func (w wrappedSigner) SignatureAlgorithm() string {
+	return w.key.SignatureAlgorithm()
+}
+
+func openFile(path string) (ucan.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	return Parse(data)
+}
+
+// ParsePEM parses the first PKCS#8 encoded Ed25519 "PRIVATE KEY" block of a
+// PEM file
+func ParsePEM(f io.Reader) (principal.Signer, error) {
+	pemData, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	var privateKey *crypto_ed25519.PrivateKey
+	rest := pemData
+
+	// Loop until no more blocks
+	for {
+		block, remaining := pem.Decode(rest)
+		if block == nil {
+			// No more PEM blocks
+			break
+		}
+		rest = remaining
+
+		// Look for "PRIVATE KEY"
+		if block.Type == "PRIVATE KEY" {
+			parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse PKCS#8 private key: %w", err)
+			}
+
+			// We expect a ed25519 private key, cast it
+			key, ok := parsedKey.(crypto_ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("the parsed key is not an ED25519 private key")
+			}
+			privateKey = &key
+			break
+		}
+	}
+
+	if privateKey == nil {
+		return nil, fmt.Errorf("could not find a PRIVATE KEY block in the PEM file")
+	}
+	return ed25519.FromRaw(*privateKey)
+}
@@ -0,0 +1,79 @@
+package keys
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePEM(t *testing.T, key principal.Signer) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(crypto_ed25519.PrivateKey(key.Raw()))
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestOpen(t *testing.T) {
+	key, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, encodePEM(t, key), 0o600))
+
+	multibase, err := ed25519.Format(key)
+	require.NoError(t, err)
+
+	t.Setenv("TEST_KEY_PEM", string(encodePEM(t, key)))
+	t.Setenv("TEST_KEY_MULTIBASE", multibase)
+
+	for _, uri := range []string{
+		path,
+		"file://" + path,
+		"env://TEST_KEY_PEM",
+		"env://TEST_KEY_MULTIBASE",
+		"multibase:" + multibase,
+	} {
+		t.Run(uri, func(t *testing.T) {
+			s, err := Open(uri)
+			require.NoError(t, err)
+			assert.Equal(t, key.DID(), s.DID())
+
+			msg := []byte("message")
+			assert.True(t, key.Verifier().Verify(msg, s.Sign(msg)))
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Open("env://TEST_KEY_MISSING")
+		require.ErrorContains(t, err, "is not set")
+
+		_, err = Open("vault://key")
+		require.ErrorContains(t, err, "unsupported key URI scheme")
+
+		_, err = Open(filepath.Join(t.TempDir(), "missing.pem"))
+		require.Error(t, err)
+	})
+}
+
+func TestWrap(t *testing.T) {
+	key, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	web, err := did.Parse("did:web:example.com")
+	require.NoError(t, err)
+
+	s, err := Wrap(key, web)
+	require.NoError(t, err)
+	assert.Equal(t, web, s.DID())
+
+	_, err = Wrap(s, web)
+	require.ErrorContains(t, err, "is not a did:key")
+}
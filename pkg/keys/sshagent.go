@@ -0,0 +1,160 @@
+package keys
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/principal/ed25519/verifier"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAgentSigner signs with an Ed25519 key held by an ssh-agent
+type SSHAgentSigner struct {
+	agent    agent.Agent
+	key      ssh.PublicKey
+	verifier principal.Verifier
+	conn     net.Conn
+}
+
+var _ ucan.Signer = (*SSHAgentSigner)(nil)
+
+// OpenSSHAgent returns a signer for the Ed25519 key with the provided
+// fingerprint held by the ssh-agent listening on $SSH_AUTH_SOCK. The signer
+// holds a connection to the agent until it is closed.
+func OpenSSHAgent(fingerprint string) (*SSHAgentSigner, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set, is ssh-agent running?")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to ssh-agent: %w", err)
+	}
+	s, err := NewSSHAgentSigner(agent.NewClient(conn), fingerprint)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.conn = conn
+	return s, nil
+}
+
+// NewSSHAgentSigner returns a signer for the Ed25519 key held by the agent
+// whose SHA256 (SHA256:...) or MD5 (MD5:aa:bb:...) fingerprint matches, as
+// printed by ssh-add -l. An empty fingerprint selects the only Ed25519 key of
+// the agent. The agent is asked for a signature, so that a locked agent or a
+// key that cannot sign is reported here rather than when issuing.
+func NewSSHAgentSigner(a agent.Agent, fingerprint string) (*SSHAgentSigner, error) {
+	list, err := a.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing ssh-agent keys: %w", err)
+	}
+
+	var matches []ssh.PublicKey
+	for _, k := range list {
+		if k.Type() != ssh.KeyAlgoED25519 {
+			continue
+		}
+		if fingerprint == "" || matchesFingerprint(k, fingerprint) {
+			matches = append(matches, k)
+		}
+	}
+	switch {
+	case len(matches) == 0 && fingerprint == "":
+		return nil, fmt.Errorf("ssh-agent holds no Ed25519 key")
+	case len(matches) == 0:
+		return nil, fmt.Errorf("ssh-agent holds no Ed25519 key with fingerprint %s", fingerprint)
+	case len(matches) > 1:
+		return nil, fmt.Errorf("ssh-agent holds %d Ed25519 keys, select one by fingerprint", len(matches))
+	}
+
+	key := matches[0]
+	parsed, err := ssh.ParsePublicKey(key.Marshal())
+	if err != nil {
+		return nil, fmt.Errorf("parsing ssh-agent key %s: %w", ssh.FingerprintSHA256(key), err)
+	}
+	pub, ok := parsed.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported ssh-agent key %s", ssh.FingerprintSHA256(key))
+	}
+	raw, ok := pub.CryptoPublicKey().(crypto_ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("ssh-agent key %s is not an Ed25519 key", ssh.FingerprintSHA256(key))
+	}
+	v, err := verifier.FromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding ssh-agent public key: %w", err)
+	}
+	s := &SSHAgentSigner{agent: a, key: key, verifier: v}
+	if _, err := s.sign(agentProbe); err != nil {
+		return nil, fmt.Errorf("probing ssh-agent: %w", err)
+	}
+	return s, nil
+}
+
+func matchesFingerprint(key ssh.PublicKey, fingerprint string) bool {
+	md5 := ssh.FingerprintLegacyMD5(key)
+	return fingerprint == ssh.FingerprintSHA256(key) ||
+		fingerprint == "MD5:"+md5 ||
+		fingerprint == md5
+}
+
+func (s *SSHAgentSigner) DID() did.DID {
+	return s.verifier.DID()
+}
+
+func (s *SSHAgentSigner) SignatureCode() uint64 {
+	return ed25519.SignatureCode
+}
+
+func (s *SSHAgentSigner) SignatureAlgorithm() string {
+	return ed25519.SignatureAlgorithm
+}
+
+// Close closes the connection to the ssh-agent opened by OpenSSHAgent. The
+// agent of a signer returned by NewSSHAgentSigner is left to its owner.
+func (s *SSHAgentSigner) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// Verifier returns the verifier of the ssh-agent key
+func (s *SSHAgentSigner) Verifier() principal.Verifier {
+	return s.verifier
+}
+
+// Sign requests a signature from the ssh-agent. The signature is empty when
+// the agent fails to produce a valid one, which the functions of the
+// delegation package refuse with ErrSigningFailed.
+func (s *SSHAgentSigner) Sign(msg []byte) signature.SignatureView {
+	raw, err := s.sign(msg)
+	if err != nil {
+		return signature.NewSignatureView(signature.NewSignature(ed25519.SignatureCode, nil))
+	}
+	return signature.NewSignatureView(signature.NewSignature(ed25519.SignatureCode, raw))
+}
+
+func (s *SSHAgentSigner) sign(msg []byte) ([]byte, error) {
+	// ssh-ed25519 signatures are plain Ed25519 signatures of the message
+	sig, err := s.agent.Sign(s.key, msg)
+	if err != nil {
+		return nil, fmt.Errorf("requesting ssh-agent signature: %w", err)
+	}
+	if sig.Format != ssh.KeyAlgoED25519 || len(sig.Blob) != crypto_ed25519.SignatureSize {
+		return nil, fmt.Errorf("ssh-agent returned an unexpected %s signature", sig.Format)
+	}
+	if !s.verifier.Verify(msg, signature.NewSignature(ed25519.SignatureCode, sig.Blob)) {
+		return nil, fmt.Errorf("ssh-agent returned a signature that does not verify with the key of %s", s.DID())
+	}
+	return sig.Blob, nil
+}
@@ -0,0 +1,135 @@
+package keys
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	"github.com/storacha/go-ucanto/did"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+func TestSSHAgent(t *testing.T) {
+	_, raw, err := crypto_ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := ed25519.FromRaw(raw)
+	require.NoError(t, err)
+
+	pub, err := ssh.NewPublicKey(raw.Public())
+	require.NoError(t, err)
+	fingerprint := ssh.FingerprintSHA256(pub)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: raw}))
+
+	t.Run("Fingerprint", func(t *testing.T) {
+		for _, fp := range []string{fingerprint, "MD5:" + ssh.FingerprintLegacyMD5(pub), ""} {
+			s, err := NewSSHAgentSigner(keyring, fp)
+			require.NoError(t, err)
+			assert.Equal(t, key.DID(), s.DID())
+		}
+
+		_, err := NewSSHAgentSigner(keyring, "SHA256:unknown")
+		require.ErrorContains(t, err, "no Ed25519 key with fingerprint")
+	})
+
+	t.Run("Sign", func(t *testing.T) {
+		s, err := NewSSHAgentSigner(keyring, fingerprint)
+		require.NoError(t, err)
+
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+		ws, err := Wrap(s, web)
+		require.NoError(t, err)
+
+		for _, issuer := range []ucan.Signer{s, ws} {
+			d, err := mkd.MakeDelegation(issuer, audience, []string{capblob.AllocateAbility})
+			require.NoError(t, err)
+			assert.Equal(t, issuer.DID(), d.Issuer().DID())
+
+			ok, err := mkd.VerifySignature(d.Data(), key.Verifier())
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("Socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "agent.sock")
+		listener, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		defer listener.Close()
+		// closed receives when the client closes a connection to the agent
+		closed := make(chan struct{}, 1)
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					agent.ServeAgent(keyring, conn)
+					closed <- struct{}{}
+				}()
+			}
+		}()
+		waitClosed := func(t *testing.T) {
+			select {
+			case <-closed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("connection to the agent was not closed")
+			}
+		}
+		t.Setenv("SSH_AUTH_SOCK", socket)
+
+		s, err := Open("ssh-agent:" + fingerprint)
+		require.NoError(t, err)
+		assert.Equal(t, key.DID(), s.DID())
+
+		sig := s.Sign([]byte("message"))
+		assert.True(t, key.Verifier().Verify([]byte("message"), sig))
+
+		web, err := did.Parse("did:web:example.com")
+		require.NoError(t, err)
+		ws, err := Wrap(s, web)
+		require.NoError(t, err)
+		require.NoError(t, Close(ws))
+		waitClosed(t)
+		assert.Empty(t, s.Sign([]byte("message")).Raw())
+
+		// the connection is closed when no key matches
+		_, err = Open("ssh-agent:SHA256:unknown")
+		require.ErrorContains(t, err, "no Ed25519 key with fingerprint")
+		waitClosed(t)
+	})
+
+	t.Run("SignFailure", func(t *testing.T) {
+		kr := agent.NewKeyring()
+		require.NoError(t, kr.Add(agent.AddedKey{PrivateKey: raw}))
+		s, err := NewSSHAgentSigner(kr, fingerprint)
+		require.NoError(t, err)
+		require.NoError(t, kr.Lock([]byte("passphrase")))
+
+		sig := s.Sign([]byte("message"))
+		assert.Empty(t, sig.Raw())
+		_, err = mkd.MakeDelegation(s, audience, []string{capblob.AllocateAbility})
+		require.ErrorIs(t, err, mkd.ErrSigningFailed)
+
+		// a locked agent is reported when the signer is opened
+		_, err = NewSSHAgentSigner(kr, fingerprint)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,165 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// Entry is the record of an issued delegation
+type Entry struct {
+	CID        string    `json:"cid"`
+	Issuer     string    `json:"issuer"`
+	Audience   string    `json:"audience"`
+	Abilities  []string  `json:"abilities"`
+	Expiration *int      `json:"expiration,omitempty"`
+	IssuedAt   time.Time `json:"issuedAt"`
+	Delegation string    `json:"delegation"` // multibase-base64-encoded CIDv1 with embedded CAR data
+}
+
+// NewEntry builds the ledger entry of a delegation issued at the provided time
+func NewEntry(d delegation.Delegation, issuedAt time.Time) (Entry, error) {
+	encoded, err := mkd.FormatDelegation(d.Archive())
+	if err != nil {
+		return Entry{}, fmt.Errorf("formatting delegation: %w", err)
+	}
+
+	abilities := make([]string, 0, len(d.Capabilities()))
+	for _, c := range d.Capabilities() {
+		abilities = append(abilities, c.Can())
+	}
+
+	return Entry{
+		CID:        d.Link().String(),
+		Issuer:     d.Issuer().DID().String(),
+		Audience:   d.Audience().DID().String(),
+		Abilities:  abilities,
+		Expiration: d.Expiration(),
+		IssuedAt:   issuedAt.UTC(),
+		Delegation: encoded,
+	}, nil
+}
+
+// Ledger is a local, append-only record of issued delegations stored as one
+// JSON entry per line
+type Ledger struct {
+	path string
+}
+
+// DefaultPath returns the path of the ledger in the user configuration directory
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding user config directory: %w", err)
+	}
+	return filepath.Join(dir, "mkdelegation", "ledger.jsonl"), nil
+}
+
+// Open returns the ledger stored at the provided path. The file is created
+// when the first entry is recorded.
+func Open(path string) (*Ledger, error) {
+	if path == "" {
+		return nil, fmt.Errorf("ledger path is required")
+	}
+	return &Ledger{path: path}, nil
+}
+
+// Path returns the path of the ledger file
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Record appends an entry to the ledger
+func (l *Ledger) Record(e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("creating ledger directory: %w", err)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding ledger entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing ledger entry: %w", err)
+	}
+	return nil
+}
+
+// List returns every entry of the ledger in the order they were recorded
+func (l *Ledger) List() ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("decoding ledger entry on line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// Get returns the entry of the delegation with the provided CID
+func (l *Ledger) Get(cid string) (Entry, bool, error) {
+	entries, err := l.List()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	for _, e := range entries {
+		if e.CID == cid {
+			return e, true, nil
+		}
+	}
+	return Entry{}, false, nil
+}
+
+// Search returns the entries whose CID, issuer, audience or one of whose
+// abilities contains the provided term
+func (l *Ledger) Search(term string) ([]Entry, error) {
+	entries, err := l.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Entry
+	for _, e := range entries {
+		fields := append([]string{e.CID, e.Issuer, e.Audience}, e.Abilities...)
+		if slices.ContainsFunc(fields, func(f string) bool { return strings.Contains(f, term) }) {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
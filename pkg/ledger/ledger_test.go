@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+func TestLedger(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	storageNode, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	indexer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	l, err := Open(filepath.Join(t.TempDir(), "nested", "ledger.jsonl"))
+	require.NoError(t, err)
+
+	entries, err := l.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	first, err := mkd.MakeDelegation(issuer, storageNode, []string{capblob.AllocateAbility, capblob.AcceptAbility})
+	require.NoError(t, err)
+	second, err := mkd.MakeDelegation(issuer, indexer, []string{capclaim.CacheAbility})
+	require.NoError(t, err)
+
+	issuedAt := time.Now()
+	firstEntry, err := NewEntry(first, issuedAt)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(firstEntry))
+
+	secondEntry, err := NewEntry(second, issuedAt)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(secondEntry))
+
+	t.Run("List", func(t *testing.T) {
+		entries, err := l.List()
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, first.Link().String(), entries[0].CID)
+		assert.Equal(t, []string{capblob.AllocateAbility, capblob.AcceptAbility}, entries[0].Abilities)
+		assert.Equal(t, storageNode.DID().String(), entries[0].Audience)
+		assert.Equal(t, second.Link().String(), entries[1].CID)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		e, ok, err := l.Get(second.Link().String())
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		d, err := mkd.DecodeDelegation(e.Delegation)
+		require.NoError(t, err)
+		assert.Equal(t, second.Link(), d.Link())
+
+		_, ok, err = l.Get("bafynotfound")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		matches, err := l.Search("blob/")
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, first.Link().String(), matches[0].CID)
+
+		matches, err = l.Search(issuer.DID().String())
+		require.NoError(t, err)
+		assert.Len(t, matches, 2)
+	})
+}
@@ -0,0 +1,207 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/storacha/go-ucanto/ucan"
+	"gopkg.in/yaml.v3"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+// Names of the policy rules reported in violations
+const (
+	RuleAllowedAbilities = "allowed-abilities"
+	RuleMaxExpiry        = "max-expiry"
+	RuleForbidWildcards  = "forbid-wildcards"
+	RuleRequiredFacts    = "required-facts"
+)
+
+// Policy restricts the delegations that may be issued
+//
+//	grants:
+//	  - audiences: ["did:key:z6Mk..."]
+//	    abilities: ["blob/allocate", "claim/*"]
+//	  - audiences: ["*"]
+//	    abilities: ["claim/cache"]
+//	maxExpiry: 90d
+//	forbidWildcards: true
+//	requiredFacts: ["purpose"]
+type Policy struct {
+	// Grants lists which audiences may receive which abilities. When empty,
+	// any audience may receive any ability.
+	Grants []Grant `yaml:"grants"`
+	// MaxExpiry is the maximum validity of a delegation from the time it is
+	// issued, or from its not-before time. Delegations that never expire are
+	// refused when set.
+	MaxExpiry Duration `yaml:"maxExpiry"`
+	// ForbidWildcards refuses abilities containing a "*" wildcard
+	ForbidWildcards bool `yaml:"forbidWildcards"`
+	// RequiredFacts lists keys that must be present in the facts of a delegation
+	RequiredFacts []string `yaml:"requiredFacts"`
+}
+
+// Grant allows the audiences to receive the abilities. An audience of "*"
+// matches any audience, and abilities may use "*" and "namespace/*" patterns.
+type Grant struct {
+	Audiences []string `yaml:"audiences"`
+	Abilities []string `yaml:"abilities"`
+}
+
+// Violation is a policy rule broken by a delegation
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// Duration is a time.Duration that is decoded from a duration string as
+// accepted by ParseDuration
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := ParseDuration(value.Value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ParseDuration parses a duration string as accepted by time.ParseDuration,
+// or a number of days such as "90d"
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Load reads a policy from a YAML file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy file: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing policy file %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a YAML policy, refusing unknown fields
+func Parse(data []byte) (*Policy, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var p Policy
+	if err := dec.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for i, g := range p.Grants {
+		if len(g.Audiences) == 0 || len(g.Abilities) == 0 {
+			return nil, fmt.Errorf("grant %d must list audiences and abilities", i+1)
+		}
+	}
+	return &p, nil
+}
+
+// Check returns the rules of the policy broken by a delegation, or by the
+// unsigned payload of a delegation, issued at the provided time
+func (p *Policy) Check(d ucan.UCAN, issuedAt time.Time) []Violation {
+	var violations []Violation
+	add := func(rule, format string, a ...any) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, a...)})
+	}
+
+	audience := d.Audience().DID().String()
+	for _, c := range d.Capabilities() {
+		if p.ForbidWildcards && strings.Contains(c.Can(), "*") {
+			add(RuleForbidWildcards, "wildcard ability %s is forbidden", c.Can())
+		}
+		if len(p.Grants) > 0 && !p.allows(audience, c.Can()) {
+			add(RuleAllowedAbilities, "%s may not receive %s", audience, c.Can())
+		}
+	}
+
+	if p.MaxExpiry > 0 {
+		maxValidity := time.Duration(p.MaxExpiry)
+		start := issuedAt
+		if nbf := d.NotBefore(); nbf != 0 && time.Unix(int64(nbf), 0).After(start) {
+			start = time.Unix(int64(nbf), 0)
+		}
+		if exp := d.Expiration(); exp == nil {
+			add(RuleMaxExpiry, "delegation never expires, maximum validity is %s", maxValidity)
+		} else if validity := time.Unix(int64(*exp), 0).Sub(start); validity > maxValidity {
+			add(RuleMaxExpiry, "delegation is valid for %s, maximum validity is %s", validity.Round(time.Second), maxValidity)
+		}
+	}
+
+	for _, key := range p.RequiredFacts {
+		if !slices.ContainsFunc(d.Facts(), func(f map[string]any) bool {
+			_, ok := f[key]
+			return ok
+		}) {
+			add(RuleRequiredFacts, "required fact %s is missing", key)
+		}
+	}
+	return violations
+}
+
+// Enforce returns an error listing the violations of a delegation, or of the
+// unsigned payload of a delegation, issued at the provided time, or nil when
+// it complies with the policy. Paths that mint a delegation enforce it on the
+// payload before it is signed, through the issuer returned by Issuer.
+func (p *Policy) Enforce(d ucan.UCAN, issuedAt time.Time) error {
+	var errs error
+	for _, v := range p.Check(d, issuedAt) {
+		errs = multierror.Append(errs, v)
+	}
+	return errs
+}
+
+// Issuer returns an issuer that signs with the provided one only the
+// delegations that comply with the policy when they are issued: the payload
+// of each delegation issued through pkg/delegation is enforced before it is
+// signed (see mkd.CheckingIssuer).
+func (p *Policy) Issuer(issuer ucan.Signer) ucan.Signer {
+	return mkd.CheckingIssuer(issuer, func(payload ucan.UCAN) error {
+		return p.Enforce(payload, time.Now())
+	})
+}
+
+// allows reports whether a grant of the policy allows the audience to
+// receive the ability
+func (p *Policy) allows(audience, ability string) bool {
+	for _, g := range p.Grants {
+		if !slices.Contains(g.Audiences, "*") && !slices.Contains(g.Audiences, audience) {
+			continue
+		}
+		for _, a := range g.Abilities {
+			if mkd.AbilityCovers(a, ability) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+)
+
+type testFact map[string]datamodel.Node
+
+func (f testFact) ToIPLD() (map[string]datamodel.Node, error) {
+	return f, nil
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`
+grants:
+  - audiences: ["*"]
+    abilities: ["claim/*"]
+maxExpiry: 90d
+forbidWildcards: true
+requiredFacts: [purpose]
+`))
+	require.NoError(t, err)
+	assert.Len(t, p.Grants, 1)
+	assert.Equal(t, 90*24*time.Hour, time.Duration(p.MaxExpiry))
+	assert.True(t, p.ForbidWildcards)
+	assert.Equal(t, []string{"purpose"}, p.RequiredFacts)
+
+	_, err = Parse([]byte("maxExpiry: 90 days"))
+	require.Error(t, err)
+
+	_, err = Parse([]byte("unknown: true"))
+	require.Error(t, err)
+
+	_, err = Parse([]byte("grants: [{audiences: ['*']}]"))
+	require.ErrorContains(t, err, "must list audiences and abilities")
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, want := range map[string]time.Duration{"90d": 90 * 24 * time.Hour, "36h": 36 * time.Hour, "1h30m": 90 * time.Minute} {
+		d, err := ParseDuration(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, d, s)
+	}
+	for _, s := range []string{"", "d", "90 days", "1.5d"} {
+		_, err := ParseDuration(s)
+		assert.ErrorContains(t, err, "invalid duration", s)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	allowed, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	other, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	now := time.Now()
+	p := &Policy{
+		Grants: []Grant{
+			{Audiences: []string{allowed.DID().String()}, Abilities: []string{"blob/*"}},
+			{Audiences: []string{"*"}, Abilities: []string{capclaim.CacheAbility}},
+		},
+		MaxExpiry:       Duration(24 * time.Hour),
+		ForbidWildcards: true,
+		RequiredFacts:   []string{"purpose"},
+	}
+	purpose := delegation.WithFacts([]ucan.FactBuilder{testFact{"purpose": basicnode.NewString("test")}})
+	inHour := delegation.WithExpiration(int(now.Add(time.Hour).Unix()))
+
+	rules := func(violations []Violation) []string {
+		var rules []string
+		for _, v := range violations {
+			rules = append(rules, v.Rule)
+		}
+		return rules
+	}
+
+	t.Run("Compliant", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, allowed, []string{capblob.AllocateAbility, capclaim.CacheAbility}, inHour, purpose)
+		require.NoError(t, err)
+		assert.Empty(t, p.Check(d, now))
+		assert.NoError(t, p.Enforce(d, now))
+
+		d, err = mkd.MakeDelegation(issuer, other, []string{capclaim.CacheAbility}, inHour, purpose)
+		require.NoError(t, err)
+		assert.Empty(t, p.Check(d, now))
+	})
+
+	t.Run("AudienceNotAllowed", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, other, []string{capblob.AllocateAbility}, inHour, purpose)
+		require.NoError(t, err)
+		assert.Equal(t, []string{RuleAllowedAbilities}, rules(p.Check(d, now)))
+	})
+
+	t.Run("Wildcard", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, allowed, []string{"blob/*"}, inHour, purpose)
+		require.NoError(t, err)
+		assert.Equal(t, []string{RuleForbidWildcards}, rules(p.Check(d, now)))
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, allowed, []string{capblob.AllocateAbility}, delegation.WithNoExpiration(), purpose)
+		require.NoError(t, err)
+		assert.Equal(t, []string{RuleMaxExpiry}, rules(p.Check(d, now)))
+
+		d, err = mkd.MakeDelegation(issuer, allowed, []string{capblob.AllocateAbility}, delegation.WithExpiration(int(now.Add(48*time.Hour).Unix())), purpose)
+		require.NoError(t, err)
+		assert.Equal(t, []string{RuleMaxExpiry}, rules(p.Check(d, now)))
+
+		d, err = mkd.MakeDelegation(issuer, allowed, []string{capblob.AllocateAbility},
+			delegation.WithNotBefore(int(now.Add(30*time.Hour).Unix())),
+			delegation.WithExpiration(int(now.Add(48*time.Hour).Unix())),
+			purpose,
+		)
+		require.NoError(t, err)
+		assert.Empty(t, p.Check(d, now))
+	})
+
+	t.Run("MissingFact", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, allowed, []string{capblob.AllocateAbility}, inHour)
+		require.NoError(t, err)
+		violations := p.Check(d, now)
+		assert.Equal(t, []string{RuleRequiredFacts}, rules(violations))
+		assert.ErrorContains(t, p.Enforce(d, now), "required fact purpose is missing")
+	})
+
+	t.Run("Payload", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(mkd.UnsignedIssuer(issuer.DID()), other, []string{capblob.AllocateAbility}, inHour, purpose)
+		require.NoError(t, err)
+		payload, err := mkd.EncodePayload(d)
+		require.NoError(t, err)
+		u, err := mkd.DecodePayload(payload)
+		require.NoError(t, err)
+		assert.Equal(t, []string{RuleAllowedAbilities}, rules(p.Check(u, now)))
+	})
+
+	t.Run("Issuer", func(t *testing.T) {
+		_, err := mkd.MakeDelegation(p.Issuer(issuer), other, []string{capblob.AllocateAbility}, inHour, purpose)
+		var v Violation
+		require.ErrorAs(t, err, &v)
+		assert.Equal(t, RuleAllowedAbilities, v.Rule)
+
+		d, err := mkd.MakeDelegation(p.Issuer(issuer), allowed, []string{capblob.AllocateAbility}, inHour, purpose)
+		require.NoError(t, err)
+		assert.Empty(t, p.Check(d, now))
+	})
+
+	t.Run("EmptyPolicy", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, other, []string{"*"}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		assert.Empty(t, (&Policy{}).Check(d, now))
+	})
+}
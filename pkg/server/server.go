@@ -0,0 +1,347 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/storacha/go-ucanto/core/delegation"
+	"github.com/storacha/go-ucanto/did"
+	"github.com/storacha/go-ucanto/principal"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/storacha/go-ucanto/ucan/crypto/signature"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+// maxBodySize is the maximum size of a request body
+const maxBodySize = 4 << 20
+
+// Token is a bearer token accepted by the server, along with the audiences
+// and abilities it may mint delegations for. An audience of "*" allows any
+// audience, and abilities may use "*" and "namespace/*" patterns. An empty
+// allow-list allows nothing, so the token may only parse and verify.
+type Token struct {
+	Name      string   `yaml:"name" json:"name"`
+	Token     string   `yaml:"token" json:"token"`
+	Audiences []string `yaml:"audiences" json:"audiences"`
+	Abilities []string `yaml:"abilities" json:"abilities"`
+}
+
+// MintRequest is the body of a request to mint a delegation
+type MintRequest struct {
+	Audience     string   `json:"audience"`
+	Capabilities []string `json:"capabilities"`
+	Expiration   *int     `json:"expiration,omitempty"`
+}
+
+// MintResponse is the body of the response to a mint request
+type MintResponse struct {
+	Delegation string              `json:"delegation"` // multibase-base64-encoded CIDv1 with embedded CAR data
+	Info       *mkd.DelegationInfo `json:"info"`
+}
+
+// VerifyResponse is the body of the response to a verify request
+type VerifyResponse struct {
+	Valid  bool                `json:"valid"`
+	Issues []Issue             `json:"issues"`
+	Info   *mkd.DelegationInfo `json:"info"`
+}
+
+// Issue is a problem found verifying a delegation
+type Issue struct {
+	// Kind is the kind of problem: invalid-signature, expired, not-yet-valid
+	// or invalid-proof-chain
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// BundleVerifyResponse is the body of the response to a verify request for a
+// bundle of several delegations
+type BundleVerifyResponse struct {
+	Valid       bool             `json:"valid"` // All the delegations of the bundle are valid
+	Delegations []VerifyResponse `json:"delegations"`
+}
+
+// Server is an HTTP API for minting, parsing and verifying delegations
+type Server struct {
+	issuer ucan.Signer
+	tokens []Token
+	policy *policy.Policy
+	logger *slog.Logger
+	mux    *http.ServeMux
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithPolicy refuses to mint delegations that violate the policy
+func WithPolicy(p *policy.Policy) Option {
+	return func(s *Server) {
+		s.policy = p
+	}
+}
+
+// New returns a server that mints delegations with the provided issuer and
+// accepts requests authorized by one of the provided tokens. The issuer is a
+// principal.Signer, or a signer of the keys package.
+func New(issuer ucan.Signer, tokens []Token, logger *slog.Logger, opts ...Option) *Server {
+	s := &Server{issuer: issuer, tokens: tokens, logger: logger, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("POST /delegations", s.handleMint)
+	s.mux.HandleFunc("POST /parse", s.handleParse)
+	s.mux.HandleFunc("POST /verify", s.handleVerify)
+	return s
+}
+
+// statusRecorder records the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// tokenKey is the request context key of the authorized token
+type tokenKey struct{}
+
+func withToken(ctx context.Context, t Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, t)
+}
+
+func tokenFrom(ctx context.Context) Token {
+	t, _ := ctx.Value(tokenKey{}).(Token)
+	return t
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	attrs := []any{
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("remote", r.RemoteAddr),
+	}
+
+	token, ok := s.authorize(r)
+	if ok {
+		attrs = append(attrs, slog.String("token", token.Name))
+		s.mux.ServeHTTP(rec, r.WithContext(withToken(r.Context(), token)))
+	} else {
+		writeError(rec, http.StatusUnauthorized, "missing or invalid bearer token")
+	}
+
+	attrs = append(attrs, slog.Int("status", rec.status), slog.Duration("duration", time.Since(start)))
+	s.logger.Info("request", attrs...)
+}
+
+// authorize returns the token presented as bearer token in the request
+func (s *Server) authorize(r *http.Request) (Token, bool) {
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || presented == "" {
+		return Token{}, false
+	}
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(presented)) == 1 {
+			return t, true
+		}
+	}
+	return Token{}, false
+}
+
+func (s *Server) handleMint(w http.ResponseWriter, r *http.Request) {
+	var req MintRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decoding request: %s", err))
+		return
+	}
+	if len(req.Capabilities) == 0 {
+		writeError(w, http.StatusBadRequest, "no capabilities requested")
+		return
+	}
+
+	if err := mkd.ValidateCapabilities(req.Capabilities); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token := tokenFrom(r.Context())
+	if !slices.Contains(token.Audiences, "*") && !slices.Contains(token.Audiences, req.Audience) {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("token may not delegate to audience %s", req.Audience))
+		return
+	}
+	for _, c := range req.Capabilities {
+		if !slices.ContainsFunc(token.Abilities, func(a string) bool { return mkd.AbilityCovers(a, c) }) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("token may not delegate ability %s", c))
+			return
+		}
+	}
+
+	audience, err := did.Parse(req.Audience)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parsing audience: %s", err))
+		return
+	}
+
+	var opts []delegation.Option
+	if req.Expiration != nil {
+		if int64(*req.Expiration) < time.Now().Unix() {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("expiration %d is in the past", *req.Expiration))
+			return
+		}
+		opts = append(opts, delegation.WithExpiration(*req.Expiration))
+	} else {
+		opts = append(opts, delegation.WithNoExpiration())
+	}
+
+	// The policy is enforced on the payload, before the issuer signs it
+	issuer := s.issuer
+	if s.policy != nil {
+		issuer = s.policy.Issuer(issuer)
+	}
+	d, err := mkd.MakeDelegation(issuer, audience, req.Capabilities, opts...)
+	if err != nil {
+		if errors.As(err, new(policy.Violation)) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("delegation violates policy: %s", err))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("making delegation: %s", err))
+		return
+	}
+	out, err := mkd.FormatDelegation(d.Archive())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("formatting delegation: %s", err))
+		return
+	}
+	info := mkd.Inspect(d)
+
+	s.logger.Info("minted delegation",
+		slog.String("cid", d.Link().String()),
+		slog.String("audience", req.Audience),
+		slog.Any("capabilities", req.Capabilities),
+		slog.String("token", token.Name),
+	)
+	writeJSON(w, http.StatusCreated, MintResponse{Delegation: out, Info: info})
+}
+
+func (s *Server) handleParse(w http.ResponseWriter, r *http.Request) {
+	ds, ok := readDelegations(w, r)
+	if !ok {
+		return
+	}
+	infos := make([]*mkd.DelegationInfo, 0, len(ds))
+	for _, d := range ds {
+		info := mkd.Inspect(d)
+		mkd.AnnotateExpiry(info, time.Now(), 0)
+		infos = append(infos, info)
+	}
+	if len(infos) == 1 {
+		writeJSON(w, http.StatusOK, infos[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	ds, ok := readDelegations(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if len(ds) == 1 {
+		writeJSON(w, http.StatusOK, s.verify(ds[0], now))
+		return
+	}
+
+	resp := BundleVerifyResponse{Valid: true}
+	for _, d := range ds {
+		v := s.verify(d, now)
+		resp.Valid = resp.Valid && v.Valid
+		resp.Delegations = append(resp.Delegations, v)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// verify verifies a delegation and its proof chain
+func (s *Server) verify(d delegation.Delegation, now time.Time) VerifyResponse {
+	info := mkd.Inspect(d)
+	mkd.AnnotateExpiry(info, now, 0)
+	issues := []Issue{}
+	for _, err := range mkd.VerifyDelegation(d, now, s.resolveVerifier) {
+		issues = append(issues, Issue{Kind: issueKind(err), Message: err.Error()})
+	}
+	return VerifyResponse{Valid: len(issues) == 0, Issues: issues, Info: info}
+}
+
+// issueKind returns the kind of a problem returned by mkd.VerifyDelegation
+func issueKind(err error) string {
+	switch {
+	case errors.Is(err, mkd.ErrInvalidSignature):
+		return "invalid-signature"
+	case errors.Is(err, mkd.ErrExpired):
+		return "expired"
+	case errors.Is(err, mkd.ErrNotYetValid):
+		return "not-yet-valid"
+	default:
+		return "invalid-proof-chain"
+	}
+}
+
+// resolveVerifier resolves the key of the server issuer, which may be a
+// did:web, and of did:key principals
+func (s *Server) resolveVerifier(id did.DID) (signature.Verifier, error) {
+	if vs, ok := s.issuer.(interface{ Verifier() principal.Verifier }); ok && id == s.issuer.DID() {
+		return vs.Verifier(), nil
+	}
+	return mkd.ResolveDIDKey(id)
+}
+
+// readDelegations reads the delegation, or the bundle of delegations, in the
+// request body, encoded as for mkd.DecodeBundleBytes
+func readDelegations(w http.ResponseWriter, r *http.Request) ([]delegation.Delegation, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		} else {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("reading request: %s", err))
+		}
+		return nil, false
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		writeError(w, http.StatusBadRequest, "no delegation provided")
+		return nil, false
+	}
+	ds, err := mkd.DecodeBundleBytes(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return ds, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
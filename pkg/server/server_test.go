@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	capblob "github.com/storacha/go-libstoracha/capabilities/blob"
+	capclaim "github.com/storacha/go-libstoracha/capabilities/claim"
+	"github.com/storacha/go-ucanto/core/delegation"
+	ed25519 "github.com/storacha/go-ucanto/principal/ed25519/signer"
+	"github.com/storacha/go-ucanto/ucan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mkd "github.com/storacha/go-mkdelegation/pkg/delegation"
+	"github.com/storacha/go-mkdelegation/pkg/policy"
+)
+
+// lockedBuffer is a buffer that is safe to write to from the server goroutines
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestServer(t *testing.T) {
+	issuer, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	audience, err := ed25519.Generate()
+	require.NoError(t, err)
+
+	var logs lockedBuffer
+	srv := httptest.NewServer(New(issuer, []Token{
+		{Name: "admin", Token: "admin-token", Audiences: []string{"*"}, Abilities: []string{"*"}},
+		{Name: "reader", Token: "reader-token"},
+		{Name: "restricted", Token: "restricted-token", Audiences: []string{audience.DID().String()}, Abilities: []string{capblob.AllocateAbility}},
+	}, slog.New(slog.NewJSONHandler(&logs, nil))))
+	defer srv.Close()
+
+	post := func(t *testing.T, path, token, body string) (int, []byte) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		out, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res.StatusCode, out
+	}
+
+	mint := func(t *testing.T, token string, req MintRequest) (int, []byte) {
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		return post(t, "/delegations", token, string(body))
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		status, _ := post(t, "/parse", "", "x")
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		status, _ = post(t, "/parse", "wrong", "x")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("Mint", func(t *testing.T) {
+		status, body := mint(t, "admin-token", MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility, capclaim.CacheAbility}})
+		require.Equal(t, http.StatusCreated, status, string(body))
+
+		var res MintResponse
+		require.NoError(t, json.Unmarshal(body, &res))
+		assert.Equal(t, issuer.DID().String(), res.Info.Issuer)
+		assert.Equal(t, audience.DID().String(), res.Info.Audience)
+		require.Len(t, res.Info.Capabilities, 2)
+		assert.Nil(t, res.Info.Expiration)
+
+		d, err := mkd.DecodeDelegation(res.Delegation)
+		require.NoError(t, err)
+		assert.Equal(t, res.Info.CID, d.Link().String())
+	})
+
+	t.Run("AllowList", func(t *testing.T) {
+		status, _ := mint(t, "restricted-token", MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility}})
+		assert.Equal(t, http.StatusCreated, status)
+
+		status, _ = mint(t, "restricted-token", MintRequest{Audience: audience.DID().String(), Capabilities: []string{capclaim.CacheAbility}})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		status, _ = mint(t, "restricted-token", MintRequest{Audience: issuer.DID().String(), Capabilities: []string{capblob.AllocateAbility}})
+		assert.Equal(t, http.StatusForbidden, status)
+
+		// an empty allow-list allows nothing
+		status, _ = mint(t, "reader-token", MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility}})
+		assert.Equal(t, http.StatusForbidden, status)
+		status, _ = post(t, "/parse", "reader-token", "not a delegation")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("UnknownCapability", func(t *testing.T) {
+		status, body := mint(t, "admin-token", MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility, "custom/thing"}})
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Contains(t, string(body), "unknown capability: custom/thing")
+	})
+
+	t.Run("Policy", func(t *testing.T) {
+		p := &policy.Policy{MaxExpiry: policy.Duration(24 * time.Hour)}
+		restricted := httptest.NewServer(New(issuer, []Token{{Name: "admin", Token: "admin-token", Audiences: []string{"*"}, Abilities: []string{"*"}}}, slog.New(slog.NewJSONHandler(io.Discard, nil)), WithPolicy(p)))
+		defer restricted.Close()
+
+		mintWithPolicy := func(req MintRequest) (int, string) {
+			body, err := json.Marshal(req)
+			require.NoError(t, err)
+			r, err := http.NewRequest(http.MethodPost, restricted.URL+"/delegations", bytes.NewReader(body))
+			require.NoError(t, err)
+			r.Header.Set("Authorization", "Bearer admin-token")
+			res, err := http.DefaultClient.Do(r)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			out, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			return res.StatusCode, string(out)
+		}
+
+		inHour := int(time.Now().Add(time.Hour).Unix())
+		status, body := mintWithPolicy(MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility}, Expiration: &inHour})
+		assert.Equal(t, http.StatusCreated, status, body)
+
+		status, body = mintWithPolicy(MintRequest{Audience: audience.DID().String(), Capabilities: []string{capblob.AllocateAbility}})
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Contains(t, body, "never expires")
+	})
+
+	t.Run("ParseAndVerify", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		encoded, err := mkd.FormatDelegation(d.Archive())
+		require.NoError(t, err)
+
+		status, body := post(t, "/parse", "admin-token", encoded)
+		require.Equal(t, http.StatusOK, status, string(body))
+		var info mkd.DelegationInfo
+		require.NoError(t, json.Unmarshal(body, &info))
+		assert.Equal(t, d.Link().String(), info.CID)
+
+		status, body = post(t, "/verify", "admin-token", encoded)
+		require.Equal(t, http.StatusOK, status, string(body))
+		var res VerifyResponse
+		require.NoError(t, json.Unmarshal(body, &res))
+		assert.True(t, res.Valid)
+		assert.Empty(t, res.Issues)
+	})
+
+	t.Run("VerifyExpired", func(t *testing.T) {
+		d, err := mkd.MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithExpiration(ucan.Now()-10))
+		require.NoError(t, err)
+		encoded, err := mkd.FormatDelegation(d.Archive())
+		require.NoError(t, err)
+
+		status, body := post(t, "/verify", "admin-token", encoded)
+		require.Equal(t, http.StatusOK, status, string(body))
+		var res VerifyResponse
+		require.NoError(t, json.Unmarshal(body, &res))
+		assert.False(t, res.Valid)
+		require.Len(t, res.Issues, 1)
+		assert.Equal(t, "expired", res.Issues[0].Kind)
+		assert.Contains(t, res.Issues[0].Message, "expired")
+	})
+
+	t.Run("Bundle", func(t *testing.T) {
+		valid, err := mkd.MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithNoExpiration())
+		require.NoError(t, err)
+		expired, err := mkd.MakeDelegation(issuer, audience, []string{capblob.AllocateAbility}, delegation.WithExpiration(ucan.Now()-10))
+		require.NoError(t, err)
+		archive, err := mkd.Bundle(valid, expired)
+		require.NoError(t, err)
+		encoded, err := mkd.FormatDelegationBytes(archive)
+		require.NoError(t, err)
+
+		for _, content := range []string{encoded, string(archive)} {
+			status, body := post(t, "/parse", "admin-token", content)
+			require.Equal(t, http.StatusOK, status, string(body))
+			var infos []mkd.DelegationInfo
+			require.NoError(t, json.Unmarshal(body, &infos))
+			require.Len(t, infos, 2)
+			assert.Equal(t, valid.Link().String(), infos[0].CID)
+			assert.Equal(t, expired.Link().String(), infos[1].CID)
+
+			status, body = post(t, "/verify", "admin-token", content)
+			require.Equal(t, http.StatusOK, status, string(body))
+			var res BundleVerifyResponse
+			require.NoError(t, json.Unmarshal(body, &res))
+			assert.False(t, res.Valid)
+			require.Len(t, res.Delegations, 2)
+			assert.True(t, res.Delegations[0].Valid)
+			assert.False(t, res.Delegations[1].Valid)
+		}
+	})
+
+	t.Run("InvalidDelegation", func(t *testing.T) {
+		status, _ := post(t, "/parse", "admin-token", "not a delegation")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Logs", func(t *testing.T) {
+		var entry map[string]any
+		line, _, _ := strings.Cut(logs.String(), "\n")
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, "request", entry["msg"])
+		assert.Equal(t, "/parse", entry["path"])
+		assert.EqualValues(t, http.StatusUnauthorized, entry["status"])
+		assert.NotContains(t, logs.String(), "admin-token")
+	})
+}